@@ -0,0 +1,59 @@
+// Command webhookreceiver is a local stand-in for a seller integration. It
+// verifies webhook signatures and logs every delivery, which makes it easy to
+// exercise registration, retries and redelivery without a real endpoint.
+//
+//	WEBHOOK_SECRET=whsec_... WEBHOOK_FAIL_FIRST=2 go run ./cmd/webhookreceiver
+//
+// Webhooks only go to public https URLs by default, so start the API with
+// WEBHOOK_ALLOW_PRIVATE=true and register http://localhost:4000/ as the
+// endpoint URL. WEBHOOK_FAIL_FIRST makes the first N deliveries answer 500 so
+// the backoff can be observed.
+package main
+
+import (
+	"gofiber-marketplace/src/helpers"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+)
+
+func main() {
+	secret := os.Getenv("WEBHOOK_SECRET")
+	failFirst, _ := strconv.Atoi(os.Getenv("WEBHOOK_FAIL_FIRST"))
+	addr := os.Getenv("WEBHOOK_RECEIVER_ADDR")
+	if addr == "" {
+		addr = ":4000"
+	}
+
+	var received int64
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		payload, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		timestamp, _ := strconv.ParseInt(r.Header.Get("X-Webhook-Timestamp"), 10, 64)
+		signature := r.Header.Get("X-Webhook-Signature")
+		if secret != "" && !helpers.VerifyWebhookSignature(secret, timestamp, payload, signature) {
+			log.Printf("delivery %s: invalid signature", r.Header.Get("X-Webhook-Delivery"))
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		if count := atomic.AddInt64(&received, 1); count <= int64(failFirst) {
+			log.Printf("delivery %s (%s): simulated failure %d/%d", r.Header.Get("X-Webhook-Delivery"), r.Header.Get("X-Webhook-Event"), count, failFirst)
+			http.Error(w, "simulated failure", http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("delivery %s (%s): %s", r.Header.Get("X-Webhook-Delivery"), r.Header.Get("X-Webhook-Event"), payload)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Printf("webhook receiver listening on %s", addr)
+	log.Fatal(http.ListenAndServe(addr, nil))
+}
@@ -1,10 +1,18 @@
 package main
 
 import (
+	"context"
 	"gofiber-marketplace/src/configs"
+	"gofiber-marketplace/src/controllers"
 	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/models"
 	"gofiber-marketplace/src/routes"
+	"gofiber-marketplace/src/services"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -16,6 +24,14 @@ func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Fatal("Error loading .env file")
 	}
+
+	fiber.SetParserDecoder(fiber.ParserConfig{
+		IgnoreUnknownKeys: true,
+		ZeroEmpty:         true,
+		ParserType: []fiber.ParserType{
+			{Customtype: models.Money(0), Converter: helpers.ParseMoneyParam},
+		},
+	})
 	app := fiber.New()
 
 	app.Use(helmet.New())
@@ -27,11 +43,50 @@ func main() {
 		ExposeHeaders: "Content-Length",
 	}))
 
+	for _, provider := range services.DefaultShippingProviders {
+		if err := provider.Validate(); err != nil {
+			log.Fatalf("Invalid shipping config: %v", err)
+		}
+	}
+
 	configs.InitDB()
 	helpers.Migration()
+	helpers.BootstrapAdmin()
 	routes.Router(app)
 
-	if err := app.Listen(":3000"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	runner := services.NewJobRunner(4, time.Second)
+	runner.Every("vacation.expire", time.Hour, helpers.EndExpiredVacations)
+	runner.Every("webhook.deliver", 10*time.Second, helpers.DeliverDueWebhooks)
+	runner.Every("loyalty.expire", time.Hour, helpers.ExpireLoyaltyPoints)
+	runner.Every("jobs.cleanup", time.Hour, helpers.CleanupFinishedJobs)
+	runner.Every("orders.expire", time.Minute, helpers.ExpireUnpaidOrders)
+	runner.Every("orders.complete", time.Hour, helpers.CompleteDeliveredOrders)
+	controllers.RegisterJobHandlers(runner)
+	runner.Start()
+
+	listenCtx, stopListening := context.WithCancel(context.Background())
+	go services.DefaultBroker.Listen(listenCtx, os.Getenv("URL"))
+	go services.DefaultChatHub.Listen(listenCtx, os.Getenv("URL"), controllers.ChatMessageEvent)
+
+	go func() {
+		if err := app.Listen(":3000"); err != nil {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	log.Println("Shutting down")
+	stopListening()
+	if err := app.ShutdownWithTimeout(10 * time.Second); err != nil {
+		log.Printf("Failed to shut down server: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := runner.Shutdown(ctx); err != nil {
+		log.Printf("Background jobs did not finish: %v", err)
 	}
 }
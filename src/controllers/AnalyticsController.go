@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const topProductsLimit = 10
+
+func GetSellerAnalytics(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	period, err := helpers.ParseAnalyticsRange(c.Query("from"), c.Query("to"), c.Query("granularity"), time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    err.Error(),
+		})
+	}
+
+	series := models.SelectSellerSalesSeries(int(seller.ID), period.Granularity, period.From, period.To)
+	topProducts := models.SelectSellerTopProducts(int(seller.ID), period.From, period.To, topProductsLimit)
+
+	resultSeries := make([]map[string]interface{}, len(series))
+	for i, row := range series {
+		resultSeries[i] = map[string]interface{}{
+			"period":              row.Period.Format("2006-01-02"),
+			"revenue":             row.Revenue,
+			"orders":              row.Orders,
+			"units":               row.Units,
+			"average_order_value": helpers.AverageOrderValue(row.Revenue, row.Orders),
+			"views":               row.Views,
+			"conversion_rate":     helpers.ConversionRate(row.Orders, row.Views),
+		}
+	}
+
+	resultProducts := make([]map[string]interface{}, len(topProducts))
+	for i, product := range topProducts {
+		resultProducts[i] = map[string]interface{}{
+			"product_id":      product.ProductID,
+			"name":            product.Name,
+			"revenue":         product.Revenue,
+			"orders":          product.Orders,
+			"units":           product.Units,
+			"views":           product.Views,
+			"conversion_rate": helpers.ConversionRate(product.Orders, product.Views),
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data": fiber.Map{
+			"currency":     seller.Currency,
+			"from":         period.From.Format("2006-01-02"),
+			"to":           period.To.AddDate(0, 0, -1).Format("2006-01-02"),
+			"granularity":  period.Granularity,
+			"totals":       helpers.SumSalesPeriods(series),
+			"series":       resultSeries,
+			"top_products": resultProducts,
+		},
+	})
+}
+
+func ExportSellerAnalytics(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	period, err := helpers.ParseAnalyticsRange(c.Query("from"), c.Query("to"), c.Query("granularity"), time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    err.Error(),
+		})
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	records := [][]string{helpers.SalesCSVHeader}
+	for _, row := range models.SelectSellerSalesSeries(int(seller.ID), period.Granularity, period.From, period.To) {
+		records = append(records, helpers.SalesCSVRecord(row))
+	}
+
+	if err := writer.WriteAll(records); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to export analytics",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="sales_%s_%s.csv"`, period.From.Format("20060102"), period.To.AddDate(0, 0, -1).Format("20060102")))
+	return c.Status(fiber.StatusOK).Send(buffer.Bytes())
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// buildCart prices the items the way checkout charges them. Errors carry the
+// status the request should fail with.
+func buildCart(items []CartItem, now time.Time) (*helpers.Cart, *fiber.Error) {
+	var cart helpers.Cart
+	for _, item := range items {
+		product := models.SelectProductById(int(item.ProductID))
+		if product.ID == 0 || product.Status != models.ListingActive {
+			return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("Product with ID %d not found", item.ProductID))
+		}
+
+		if helpers.IsOnVacation(&product.Seller, now) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Seller of product with ID %d is on vacation", item.ProductID))
+		}
+
+		if cart.Currency != "" && product.Currency != cart.Currency {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Products in the cart must share the same currency")
+		}
+
+		cart.Add(product, models.SelectActivePromotionByProductId(int(product.ID), now), item.Quantity)
+	}
+	return &cart, nil
+}
+
+func errorResponse(c *fiber.Ctx, err *fiber.Error) error {
+	return c.Status(err.Code).JSON(fiber.Map{
+		"status":     utils.ToLower(utils.StatusMessage(err.Code)),
+		"statusCode": err.Code,
+		"message":    err.Message,
+	})
+}
+
+type CartTotalsRequest struct {
+	Items  []CartItem `json:"items" validate:"required,min=1,dive"`
+	Points int        `json:"points" validate:"gte=0"`
+}
+
+func GetCartTotals(c *fiber.Ctx) error {
+	var totalsData CartTotalsRequest
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "customer" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	if err := c.BodyParser(&totalsData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	if errors := helpers.StructValidation(&totalsData); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	cart, cartErr := buildCart(totalsData.Items, time.Now())
+	if cartErr != nil {
+		return errorResponse(c, cartErr)
+	}
+
+	var pointsUsed int
+	var pointsDiscount models.Money
+	if totalsData.Points > 0 {
+		customer := models.SelectCustomerByUserId(int(id))
+		if customer.ID == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":     "not found",
+				"statusCode": 404,
+				"message":    "Customer not found",
+			})
+		}
+
+		if pointsUsed, pointsDiscount, cartErr = applyPoints(cart, customer.ID, totalsData.Points); cartErr != nil {
+			return errorResponse(c, cartErr)
+		}
+	}
+	cart.Calculate()
+
+	pointsEarned, _ := helpers.PointsForPurchase(cart.Total, cart.Currency)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data": fiber.Map{
+			"currency":        cart.Currency,
+			"subtotal":        cart.Subtotal,
+			"tax":             cart.Tax,
+			"points_used":     pointsUsed,
+			"points_discount": pointsDiscount,
+			"total":           cart.Total,
+			"points_earned":   pointsEarned,
+			"taxes":           cart.Taxes,
+		},
+	})
+}
+
+// applyPoints takes the discount for up to points loyalty points off the
+// cart. Like coupons, points come off before tax.
+func applyPoints(cart *helpers.Cart, customerId uint, points int) (int, models.Money, *fiber.Error) {
+	if models.SelectPointBalance(int(customerId)) < points {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "Insufficient points")
+	}
+
+	pointsUsed, pointsDiscount, err := helpers.PointsDiscount(points, helpers.Payable(cart.Lines()), cart.Currency)
+	if err != nil {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Points cannot be used on products priced in %s", cart.Currency))
+	}
+
+	cart.ApplyDiscount(cart.Lines(), pointsDiscount)
+	return pointsUsed, pointsDiscount, nil
+}
@@ -1,9 +1,11 @@
 package controllers
 
 import (
+	"encoding/json"
 	"gofiber-marketplace/src/helpers"
 	"gofiber-marketplace/src/middlewares"
 	"gofiber-marketplace/src/models"
+	"math"
 	"strconv"
 	"strings"
 
@@ -13,8 +15,11 @@ import (
 func GetAllCategories(c *fiber.Ctx) error {
 	keyword := c.Query("search")
 	sort := helpers.GetSortParams(c.Query("sorting"), c.Query("orderBy"))
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountCategories(keyword)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
 
-	categories := models.SelectAllCategories(keyword, sort)
+	categories := models.SelectAllCategories(keyword, sort, limit, offset)
 	if len(categories) == 0 {
 		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
 			"status":     "no content",
@@ -25,37 +30,26 @@ func GetAllCategories(c *fiber.Ctx) error {
 
 	resultCategories := make([]map[string]interface{}, len(categories))
 	for i, category := range categories {
-		products := make([]map[string]interface{}, len(category.Products))
-		for j, product := range category.Products {
-			products[j] = map[string]interface{}{
-				"id":         product.ID,
-				"created_at": product.CreatedAt,
-				"updated_at": product.UpdatedAt,
-				"name":       product.Name,
-				"price":      product.Price,
-				"photo":      product.Image,
-				"size":       product.Size,
-				"color":      product.Color,
-				"rating":     product.Rating,
-			}
-		}
-
 		resultCategories[i] = map[string]interface{}{
-			"id":         category.ID,
-			"created_at": category.CreatedAt,
-			"updated_at": category.UpdatedAt,
-			"name":       category.Name,
-			"image":      category.Image,
-			"slug":       category.Slug,
-			"products":   products,
+			"id":            category.ID,
+			"created_at":    category.CreatedAt,
+			"updated_at":    category.UpdatedAt,
+			"name":          category.Name,
+			"image":         category.Image,
+			"slug":          category.Slug,
+			"product_count": category.ProductCount,
 		}
 	}
 
 	// return c.JSON(categories)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"status":     "success",
-		"statusCode": 200,
-		"data":       resultCategories,
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultCategories,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
 	})
 }
 
@@ -78,12 +72,66 @@ func GetCategoryById(c *fiber.Ctx) error {
 		})
 	}
 
-	products := make([]map[string]interface{}, len(category.Products))
-	for i, product := range category.Products {
-		products[i] = map[string]interface{}{
+	resultCategory := map[string]interface{}{
+		"id":              category.ID,
+		"created_at":      category.CreatedAt,
+		"updated_at":      category.UpdatedAt,
+		"name":            category.Name,
+		"image":           category.Image,
+		"slug":            category.Slug,
+		"commission_rate": helpers.CommissionRate(category),
+		"tax_rate":        helpers.TaxRate(category),
+		"product_count":   category.ProductCount,
+	}
+
+	// return c.JSON(category)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data":       resultCategory,
+	})
+}
+
+func GetCategoryProducts(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	if category := models.SelectCategoryById(id); category.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Category not found",
+		})
+	}
+
+	sort := helpers.GetSortParams(c.Query("sorting"), c.Query("orderBy"))
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountProductsByCategoryId(id)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	products := models.SelectProductsByCategoryId(id, sort, limit, offset)
+	if len(products) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Product in this category is empty",
+		})
+	}
+
+	resultProducts := make([]map[string]interface{}, len(products))
+	for i, product := range products {
+		resultProducts[i] = map[string]interface{}{
 			"id":         product.ID,
 			"created_at": product.CreatedAt,
 			"updated_at": product.UpdatedAt,
+			"brand_id":   product.SellerID,
+			"brand_name": product.Seller.Name,
 			"name":       product.Name,
 			"price":      product.Price,
 			"photo":      product.Image,
@@ -93,24 +141,33 @@ func GetCategoryById(c *fiber.Ctx) error {
 		}
 	}
 
-	resultCategory := map[string]interface{}{
-		"id":         category.ID,
-		"created_at": category.CreatedAt,
-		"updated_at": category.UpdatedAt,
-		"name":       category.Name,
-		"image":      category.Image,
-		"slug":       category.Slug,
-		"products":   products,
-	}
-
-	// return c.JSON(category)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"status":     "success",
-		"statusCode": 200,
-		"data":       resultCategory,
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultProducts,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
 	})
 }
 
+// categoryRateFields returns the admin-only rate fields sent in the request.
+// A JSON null counts as sent, since it clears the rate.
+func categoryRateFields(c *fiber.Ctx, category *models.Category) map[string]interface{} {
+	var body map[string]json.RawMessage
+	json.Unmarshal(c.Body(), &body)
+
+	rates := make(map[string]interface{})
+	if _, ok := body["commission_rate"]; ok || category.CommissionRate != nil {
+		rates["commission_rate"] = category.CommissionRate
+	}
+	if _, ok := body["tax_rate"]; ok || category.TaxRate != nil {
+		rates["tax_rate"] = category.TaxRate
+	}
+	return rates
+}
+
 func CreateCategory(c *fiber.Ctx) error {
 	var newCategory models.Category
 	if err := c.BodyParser(&newCategory); err != nil {
@@ -121,6 +178,15 @@ func CreateCategory(c *fiber.Ctx) error {
 		})
 	}
 
+	rates := categoryRateFields(c, &newCategory)
+	if role := middlewares.UserLocals(c)["role"].(string); role != "admin" && len(rates) > 0 {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Only admins can set commission and tax rates",
+		})
+	}
+
 	if newCategory.Slug == "" || newCategory.Slug != strings.ReplaceAll(strings.ToLower(newCategory.Name), " ", "") {
 		newCategory.Slug = strings.ReplaceAll(strings.ToLower(newCategory.Name), " ", "")
 	}
@@ -187,6 +253,15 @@ func UpdateCategory(c *fiber.Ctx) error {
 		})
 	}
 
+	rates := categoryRateFields(c, &updatedCategory)
+	if role := middlewares.UserLocals(c)["role"].(string); role != "admin" && len(rates) > 0 {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Only admins can set commission and tax rates",
+		})
+	}
+
 	if updatedCategory.Slug == "" || updatedCategory.Slug != strings.ReplaceAll(strings.ToLower(updatedCategory.Name), " ", "") {
 		updatedCategory.Slug = strings.ReplaceAll(strings.ToLower(updatedCategory.Name), " ", "")
 	}
@@ -210,7 +285,7 @@ func UpdateCategory(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := models.UpdateCategory(id, category); err != nil {
+	if err := models.UpdateCategory(id, category, rates); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":     "server error",
 			"statusCode": 500,
@@ -0,0 +1,535 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"gofiber-marketplace/src/services"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// chatParticipant resolves the customer or seller profile behind a token.
+// profileId is 0 when the user has no profile that can chat.
+func chatParticipant(auth jwt.MapClaims) (role string, userId uint, profileId uint) {
+	role, _ = auth["role"].(string)
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return role, 0, 0
+	}
+
+	switch role {
+	case "customer":
+		profileId = models.SelectCustomerByUserId(int(id)).ID
+	case "seller":
+		profileId = models.SelectSellerByUserId(int(id)).ID
+	}
+	return role, uint(id), profileId
+}
+
+func canAccessConversation(conversation *models.Conversation, role string, profileId uint) bool {
+	if conversation.ID == 0 || profileId == 0 {
+		return false
+	}
+
+	return (role == "customer" && conversation.CustomerID == profileId) ||
+		(role == "seller" && conversation.SellerID == profileId)
+}
+
+func conversationPartner(conversation *models.Conversation, userId uint) uint {
+	if conversation.Customer.UserID == userId {
+		return conversation.Seller.UserID
+	}
+	return conversation.Customer.UserID
+}
+
+func chatMessageResult(message *models.ChatMessage) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              message.ID,
+		"created_at":      message.CreatedAt,
+		"conversation_id": message.ConversationID,
+		"sender_id":       message.SenderID,
+		"body":            message.Body,
+		"image":           message.Image,
+		"read_at":         message.ReadAt,
+		"delivered_at":    message.DeliveredAt,
+	}
+}
+
+// ChatMessageEvent is the socket event for a new message.
+func ChatMessageEvent(message *models.ChatMessage) interface{} {
+	return fiber.Map{"type": "message", "data": chatMessageResult(message)}
+}
+
+// chatNotifyDelay gives a message time to reach an open chat socket before
+// the recipient is notified about it instead.
+const chatNotifyDelay = 10 * time.Second
+
+// sendChatMessage stores a message and pushes it to every open socket of both
+// participants, so the sender's other devices stay in sync too. The recipient
+// is only notified if none of their sockets got it (see handleChatMessage).
+func sendChatMessage(conversation *models.Conversation, userId uint, body, image string) (*models.ChatMessage, []*helpers.ErrorResponse, error) {
+	newMessage := models.ChatMessage{
+		ConversationID: conversation.ID,
+		SenderID:       userId,
+		Body:           body,
+		Image:          image,
+	}
+
+	message := middlewares.XSSMiddleware(&newMessage).(*models.ChatMessage)
+	if errors := helpers.StructValidation(message); len(errors) > 0 {
+		return nil, errors, nil
+	}
+
+	if message.Image != "" && !services.IsUploadedImage(message.Image) {
+		return nil, []*helpers.ErrorResponse{{ErrorMessage: "image must contain uploaded image"}}, nil
+	}
+
+	if err := models.CreateChatMessage(message, chatNotifyDelay); err != nil {
+		return nil, nil, err
+	}
+
+	partnerId := conversationPartner(conversation, userId)
+	services.DefaultChatHub.PublishMessage([]uint{userId, partnerId}, message, ChatMessageEvent(message))
+
+	return message, nil, nil
+}
+
+func handleChatMessage(ctx context.Context, payload []byte) error {
+	var data models.ChatMessagePayload
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return err
+	}
+
+	message := models.SelectChatMessageById(int(data.MessageID))
+	if message.ID == 0 || message.DeliveredAt != nil || message.ReadAt != nil {
+		return nil
+	}
+
+	conversation := models.SelectConversationById(int(message.ConversationID))
+	if conversation.ID == 0 {
+		return nil
+	}
+
+	preview := "Sent an image"
+	if runes := []rune(message.Body); len(runes) > 100 {
+		preview = string(runes[:100]) + "..."
+	} else if len(runes) > 0 {
+		preview = message.Body
+	}
+	return services.DefaultNotifier.Notify(conversationPartner(conversation, message.SenderID), models.NotificationChatMessage, "New message", preview)
+}
+
+func markConversationRead(conversation *models.Conversation, userId uint) (int64, error) {
+	readAt := time.Now()
+	count, err := models.MarkMessagesRead(int(conversation.ID), userId, readAt)
+	if err != nil || count == 0 {
+		return count, err
+	}
+
+	services.DefaultChatHub.Publish([]uint{conversationPartner(conversation, userId)}, fiber.Map{
+		"type":            "read",
+		"conversation_id": conversation.ID,
+		"reader_id":       userId,
+		"read_at":         readAt,
+	})
+	return count, nil
+}
+
+func GetConversations(c *fiber.Ctx) error {
+	role, userId, profileId := chatParticipant(middlewares.UserLocals(c))
+	if role != "customer" && role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	if profileId == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Profile not found",
+		})
+	}
+
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+
+	var totalData int64
+	var conversations []*models.Conversation
+	if role == "customer" {
+		totalData = models.CountConversationsByCustomerId(int(profileId))
+		conversations = models.SelectConversationsByCustomerId(int(profileId), limit, offset)
+	} else {
+		totalData = models.CountConversationsBySellerId(int(profileId))
+		conversations = models.SelectConversationsBySellerId(int(profileId), limit, offset)
+	}
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	if len(conversations) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Conversation is empty",
+		})
+	}
+
+	conversationIds := make([]uint, len(conversations))
+	for i, conversation := range conversations {
+		conversationIds[i] = conversation.ID
+	}
+	unreadCounts := models.CountUnreadMessagesByConversation(conversationIds, userId)
+
+	resultConversations := make([]map[string]interface{}, len(conversations))
+	for i, conversation := range conversations {
+		resultConversation := map[string]interface{}{
+			"id":              conversation.ID,
+			"created_at":      conversation.CreatedAt,
+			"last_message_at": conversation.LastMessageAt,
+			"customer_id":     conversation.CustomerID,
+			"seller_id":       conversation.SellerID,
+			"product_id":      conversation.ProductID,
+			"unread_count":    unreadCounts[conversation.ID],
+		}
+		if role == "customer" {
+			resultConversation["name"] = conversation.Seller.Name
+			resultConversation["photo"] = conversation.Seller.Image
+		} else {
+			resultConversation["name"] = conversation.Customer.Name
+			resultConversation["photo"] = conversation.Customer.Image
+		}
+		if conversation.Product != nil {
+			resultConversation["product_name"] = conversation.Product.Name
+		}
+		resultConversations[i] = resultConversation
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultConversations,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+type ConversationRequest struct {
+	SellerID  uint  `json:"seller_id" validate:"required"`
+	ProductID *uint `json:"product_id"`
+}
+
+func CreateConversation(c *fiber.Ctx) error {
+	var conversationData ConversationRequest
+
+	role, _, customerId := chatParticipant(middlewares.UserLocals(c))
+	if role != "customer" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	if customerId == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Customer not found",
+		})
+	}
+
+	if err := c.BodyParser(&conversationData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	if errors := helpers.StructValidation(&conversationData); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	if seller := models.SelectSellerById(int(conversationData.SellerID)); seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	if conversationData.ProductID != nil {
+		product := models.SelectProductById(int(*conversationData.ProductID))
+		if product.ID == 0 || product.SellerID != conversationData.SellerID || product.Status != models.ListingActive {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":     "not found",
+				"statusCode": 404,
+				"message":    "Product not found",
+			})
+		}
+	}
+
+	if existConversation := models.SelectConversation(customerId, conversationData.SellerID, conversationData.ProductID); existConversation.ID != 0 {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    "Conversation already exists",
+			"data":       fiber.Map{"id": existConversation.ID},
+		})
+	}
+
+	newConversation := models.Conversation{
+		CustomerID:    customerId,
+		SellerID:      conversationData.SellerID,
+		ProductID:     conversationData.ProductID,
+		LastMessageAt: time.Now(),
+	}
+
+	if err := models.CreateConversation(&newConversation); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to create conversation",
+		})
+	} else {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    "Conversation created successfully",
+			"data":       fiber.Map{"id": newConversation.ID},
+		})
+	}
+}
+
+func GetConversationMessages(c *fiber.Ctx) error {
+	role, _, profileId := chatParticipant(middlewares.UserLocals(c))
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	if conversation := models.SelectConversationById(id); !canAccessConversation(conversation, role, profileId) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Conversation not found",
+		})
+	}
+
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+
+	var messages []*models.ChatMessage
+	if after, err := strconv.Atoi(c.Query("after")); err == nil {
+		messages = models.SelectMessagesAfter(id, after, limit)
+	} else {
+		messages = models.SelectMessagesByConversationId(id, limit, offset)
+	}
+	totalData := models.CountMessagesByConversationId(id)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	if len(messages) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Message is empty",
+		})
+	}
+
+	resultMessages := make([]map[string]interface{}, len(messages))
+	for i, message := range messages {
+		resultMessages[i] = chatMessageResult(message)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultMessages,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+type ChatMessageRequest struct {
+	Body  string `json:"body"`
+	Image string `json:"image"`
+}
+
+func CreateConversationMessage(c *fiber.Ctx) error {
+	var messageData ChatMessageRequest
+
+	role, userId, profileId := chatParticipant(middlewares.UserLocals(c))
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	conversation := models.SelectConversationById(id)
+	if !canAccessConversation(conversation, role, profileId) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Conversation not found",
+		})
+	}
+
+	if err := c.BodyParser(&messageData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	message, errors, err := sendChatMessage(conversation, userId, messageData.Body, messageData.Image)
+	if len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to send message",
+		})
+	} else {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    "Message sent successfully",
+			"data":       chatMessageResult(message),
+		})
+	}
+}
+
+func ReadConversation(c *fiber.Ctx) error {
+	role, userId, profileId := chatParticipant(middlewares.UserLocals(c))
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	conversation := models.SelectConversationById(id)
+	if !canAccessConversation(conversation, role, profileId) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Conversation not found",
+		})
+	}
+
+	if count, err := markConversationRead(conversation, userId); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to mark conversation with ID %d as read", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("%d messages marked as read", count),
+		})
+	}
+}
+
+type ChatEvent struct {
+	Type           string `json:"type"`
+	ConversationID int    `json:"conversation_id"`
+	Body           string `json:"body"`
+	Image          string `json:"image"`
+}
+
+// ChatSocket handles one client connection. Clients send message, typing and
+// read events; the server pushes the same event types back plus error events
+// for frames it could not handle.
+func ChatSocket(conn *websocket.Conn) {
+	auth, _ := conn.Locals("user").(jwt.MapClaims)
+	role, userId, profileId := chatParticipant(auth)
+	if profileId == 0 {
+		if err := services.WriteSocketJSON(conn, fiber.Map{"type": "error", "message": "Profile not found"}); err != nil {
+			log.Printf("Failed to write chat event to user %d: %v", userId, err)
+		}
+		return
+	}
+
+	hub := services.DefaultChatHub
+	hub.Register(userId, conn)
+	defer hub.Unregister(userId, conn)
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var event ChatEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			hub.Reply(userId, conn, fiber.Map{"type": "error", "message": "Invalid event"})
+			continue
+		}
+
+		conversation := models.SelectConversationById(event.ConversationID)
+		if !canAccessConversation(conversation, role, profileId) {
+			hub.Reply(userId, conn, fiber.Map{"type": "error", "message": "Conversation not found"})
+			continue
+		}
+
+		switch event.Type {
+		case "message":
+			if _, errors, err := sendChatMessage(conversation, userId, event.Body, event.Image); len(errors) > 0 {
+				hub.Reply(userId, conn, fiber.Map{"type": "error", "message": "Validation failed", "errors": errors})
+			} else if err != nil {
+				hub.Reply(userId, conn, fiber.Map{"type": "error", "message": "Failed to send message"})
+			}
+		case "typing":
+			hub.Publish([]uint{conversationPartner(conversation, userId)}, fiber.Map{
+				"type":            "typing",
+				"conversation_id": conversation.ID,
+				"user_id":         userId,
+			})
+		case "read":
+			if _, err := markConversationRead(conversation, userId); err != nil {
+				hub.Reply(userId, conn, fiber.Map{"type": "error", "message": "Failed to mark messages as read"})
+			}
+		default:
+			hub.Reply(userId, conn, fiber.Map{"type": "error", "message": "Unknown event type"})
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package controllers
+
+import (
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CartItem struct {
+	ProductID uint `json:"product_id" validate:"required"`
+	Quantity  int  `json:"quantity" validate:"required,gt=0"`
+	// QuotedPrice is the unit price the customer was shown. Checkout rejects
+	// the item when a sale started or ended since then.
+	QuotedPrice models.Money `json:"quoted_price" validate:"gte=0"`
+}
+
+type CouponApplyRequest struct {
+	Code  string     `json:"code" validate:"required"`
+	Items []CartItem `json:"items" validate:"required,min=1,dive"`
+}
+
+func GetCoupons(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	role := auth["role"].(string)
+	if role != "admin" && role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+
+	var coupons []*models.Coupon
+	var totalData int64
+	if role == "admin" {
+		totalData = models.CountCoupons()
+		coupons = models.SelectAllCoupons(limit, offset)
+	} else {
+		seller := models.SelectSellerByUserId(int(id))
+		if seller.ID == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":     "not found",
+				"statusCode": 404,
+				"message":    "Seller not found",
+			})
+		}
+		totalData = models.CountCouponsBySellerId(int(seller.ID))
+		coupons = models.SelectCouponsBySellerId(int(seller.ID), limit, offset)
+	}
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	if len(coupons) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Coupon is empty",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        coupons,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+func CreateCoupon(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	role := auth["role"].(string)
+	if role != "admin" && role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	var newCoupon models.Coupon
+	if err := c.BodyParser(&newCoupon); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	newCoupon.Code = strings.ToUpper(newCoupon.Code)
+	newCoupon.UsedCount = 0
+	if role == "seller" {
+		seller := models.SelectSellerByUserId(int(id))
+		if seller.ID == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":     "not found",
+				"statusCode": 404,
+				"message":    "Seller not found",
+			})
+		}
+		newCoupon.SellerID = &seller.ID
+		if newCoupon.Currency == "" {
+			newCoupon.Currency = seller.Currency
+		}
+	}
+	if newCoupon.Currency == "" {
+		newCoupon.Currency = helpers.BaseCurrency()
+	}
+
+	coupon := middlewares.XSSMiddleware(&newCoupon).(*models.Coupon)
+	if errors := couponValidation(coupon); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	if existCoupon := models.SelectCouponByCode(coupon.Code); existCoupon.ID != 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Coupon code already exists",
+		})
+	}
+
+	if err := models.CreateCoupon(coupon); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to create coupon",
+		})
+	} else {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    "Coupon created successfully",
+		})
+	}
+}
+
+func UpdateCoupon(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	role := auth["role"].(string)
+	if role != "admin" && role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	existCoupon := models.SelectCouponById(id)
+	if existCoupon.ID == 0 || !canManageCoupon(role, int(userId), existCoupon) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Coupon not found",
+		})
+	}
+
+	var updatedCoupon models.Coupon
+	if err := c.BodyParser(&updatedCoupon); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	updatedCoupon.Code = strings.ToUpper(updatedCoupon.Code)
+	updatedCoupon.UsedCount = 0
+	if role == "seller" {
+		updatedCoupon.SellerID = existCoupon.SellerID
+	}
+	if updatedCoupon.Currency == "" {
+		updatedCoupon.Currency = existCoupon.Currency
+	}
+
+	coupon := middlewares.XSSMiddleware(&updatedCoupon).(*models.Coupon)
+	if errors := couponValidation(coupon); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	if sameCoupon := models.SelectCouponByCode(coupon.Code); sameCoupon.ID != 0 && sameCoupon.ID != existCoupon.ID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Coupon code already exists",
+		})
+	}
+
+	if err := models.UpdateCoupon(id, coupon); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to update coupon with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Coupon with ID %d updated successfully", id),
+		})
+	}
+}
+
+func DeleteCoupon(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	role := auth["role"].(string)
+	if role != "admin" && role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	coupon := models.SelectCouponById(id)
+	if coupon.ID == 0 || !canManageCoupon(role, int(userId), coupon) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Coupon not found",
+		})
+	}
+
+	if err := models.DeleteCoupon(id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to delete coupon with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Coupon with ID %d deleted successfully", id),
+		})
+	}
+}
+
+func ValidateCoupon(c *fiber.Ctx) error {
+	var applyData CouponApplyRequest
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "customer" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	customer := models.SelectCustomerByUserId(int(id))
+	if customer.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Customer not found",
+		})
+	}
+
+	if err := c.BodyParser(&applyData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	apply := middlewares.XSSMiddleware(&applyData).(*CouponApplyRequest)
+	if errors := helpers.StructValidation(apply); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	coupon := models.SelectCouponByCode(strings.ToUpper(apply.Code))
+	if coupon.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Coupon not found",
+		})
+	}
+
+	cart, cartErr := buildCart(apply.Items, time.Now())
+	if cartErr != nil {
+		return errorResponse(c, cartErr)
+	}
+
+	eligibleSubtotal, discount, couponErr := applyCoupon(cart, coupon, customer.ID, time.Now())
+	if couponErr != nil {
+		return errorResponse(c, couponErr)
+	}
+
+	cart.Calculate()
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data": fiber.Map{
+			"code":              coupon.Code,
+			"currency":          coupon.Currency,
+			"subtotal":          cart.Subtotal,
+			"eligible_subtotal": eligibleSubtotal,
+			"discount":          discount,
+			"total":             cart.Subtotal - discount,
+		},
+	})
+}
+
+// applyCoupon checks that the customer can use the coupon on the cart and
+// takes its discount off the eligible lines. Usage limits are checked again
+// under a lock when the order is placed.
+func applyCoupon(cart *helpers.Cart, coupon *models.Coupon, customerId uint, now time.Time) (eligibleSubtotal, discount models.Money, err *fiber.Error) {
+	if now.Before(coupon.StartsAt) || now.After(coupon.EndsAt) {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "Coupon is not active")
+	}
+
+	if coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "Coupon usage limit reached")
+	}
+
+	if coupon.PerCustomerLimit > 0 && models.CountCouponRedemptions(int(coupon.ID), int(customerId)) >= int64(coupon.PerCustomerLimit) {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "Coupon usage limit per customer reached")
+	}
+
+	// Coupon amounts are in the coupon's currency, so the cart has to be
+	// priced in it for the subtotal and discount to add up.
+	if cart.Currency != coupon.Currency {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Coupon only applies to products priced in %s", coupon.Currency))
+	}
+
+	lines := helpers.CouponLines(coupon, cart)
+	eligibleSubtotal = helpers.Payable(lines)
+	if eligibleSubtotal == 0 {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "Coupon is not applicable to these products")
+	}
+
+	if eligibleSubtotal < coupon.MinSpend {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Minimum spend for this coupon is %s", coupon.MinSpend))
+	}
+
+	discount = helpers.CalculateCouponDiscount(coupon, eligibleSubtotal)
+	cart.ApplyDiscount(lines, discount)
+	return eligibleSubtotal, discount, nil
+}
+
+func couponValidation(coupon *models.Coupon) []*helpers.ErrorResponse {
+	errors := helpers.StructValidation(coupon)
+	if coupon.Currency != "" && !helpers.SupportedCurrency(coupon.Currency) {
+		errors = append(errors, &helpers.ErrorResponse{
+			ErrorMessage: "currency must contain supported currency",
+		})
+	}
+
+	if coupon.Type == models.Percentage && coupon.Amount != 0 {
+		errors = append(errors, &helpers.ErrorResponse{
+			ErrorMessage: "amount must contain empty for percentage coupon",
+		})
+	}
+	if coupon.Type == models.Fixed && coupon.Percent != 0 {
+		errors = append(errors, &helpers.ErrorResponse{
+			ErrorMessage: "percent must contain empty for fixed coupon",
+		})
+	}
+
+	if coupon.CategoryID != nil {
+		if category := models.SelectCategoryById(int(*coupon.CategoryID)); category.ID == 0 {
+			errors = append(errors, &helpers.ErrorResponse{
+				ErrorMessage: "category_id must contain existing category",
+			})
+		}
+	}
+
+	if coupon.SellerID != nil {
+		if seller := models.SelectSellerById(int(*coupon.SellerID)); seller.ID == 0 {
+			errors = append(errors, &helpers.ErrorResponse{
+				ErrorMessage: "seller_id must contain existing seller",
+			})
+		}
+	}
+
+	return errors
+}
+
+func canManageCoupon(role string, userId int, coupon *models.Coupon) bool {
+	if role == "admin" {
+		return true
+	}
+
+	seller := models.SelectSellerByUserId(userId)
+	return seller.ID != 0 && coupon.SellerID != nil && *coupon.SellerID == seller.ID
+}
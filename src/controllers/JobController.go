@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"gofiber-marketplace/src/services"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterJobHandlers(runner *services.JobRunner) {
+	runner.Handle(models.JobProductUpdated, handleProductUpdated)
+	runner.Handle(models.JobProductImport, handleProductImport)
+	runner.Handle(models.JobQuestionAnswered, handleQuestionAnswered)
+	runner.Handle(models.JobChatMessage, handleChatMessage)
+	runner.Handle(models.JobOrderStatus, handleOrderStatus)
+	runner.Handle(models.JobReturnStatus, handleReturnStatus)
+}
+
+func handleProductUpdated(ctx context.Context, payload []byte) error {
+	var data models.ProductUpdatedPayload
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return err
+	}
+
+	after := models.SelectProductById(int(data.ProductID))
+	if after.ID == 0 {
+		return nil
+	}
+
+	before := &models.Product{Price: data.PreviousPrice, Stock: data.PreviousStock}
+	if err := notifyProductAlerts(before, after); err != nil {
+		return err
+	}
+	return queueProductWebhooks(before, after)
+}
+
+func GetJobs(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	status := models.JobStatus(c.Query("status"))
+	if status == "" {
+		status = models.JobDead
+	}
+
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountJobsByStatus(status)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	jobs := models.SelectJobsByStatus(status, limit, offset)
+	if len(jobs) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Job is empty",
+		})
+	}
+
+	resultJobs := make([]map[string]interface{}, len(jobs))
+	for i, job := range jobs {
+		resultJobs[i] = map[string]interface{}{
+			"id":           job.ID,
+			"created_at":   job.CreatedAt,
+			"type":         job.Type,
+			"payload":      json.RawMessage(job.Payload),
+			"status":       job.Status,
+			"attempts":     job.Attempts,
+			"max_attempts": job.MaxAttempts,
+			"run_at":       job.RunAt,
+			"last_error":   job.LastError,
+			"finished_at":  job.FinishedAt,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultJobs,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+func RetryJob(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	job := models.SelectJobById(id)
+	if job.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Job not found",
+		})
+	}
+
+	if job.Status != models.JobDead {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Only dead jobs can be retried",
+		})
+	}
+
+	if err := models.RequeueDeadJob(id, time.Now()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to retry job with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Job with ID %d queued again", id),
+		})
+	}
+}
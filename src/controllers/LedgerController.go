@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetSellerBalance(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	balance := models.SelectSellerBalance(int(seller.ID), seller.Currency, time.Now())
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data": fiber.Map{
+			"pending":   balance.Pending,
+			"available": balance.Available,
+			"currency":  balance.Currency,
+			"hold_days": int(helpers.LedgerHoldPeriod().Hours() / 24),
+		},
+	})
+}
+
+func GetSellerLedger(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountLedgerEntriesBySellerId(int(seller.ID))
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	entries := models.SelectLedgerEntriesBySellerId(int(seller.ID), limit, offset)
+	if len(entries) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Ledger is empty",
+		})
+	}
+
+	resultEntries := make([]map[string]interface{}, len(entries))
+	for i, entry := range entries {
+		resultEntries[i] = map[string]interface{}{
+			"id":           entry.ID,
+			"created_at":   entry.CreatedAt,
+			"type":         entry.Type,
+			"amount":       entry.Amount,
+			"currency":     entry.Currency,
+			"reference":    entry.Reference,
+			"available_at": entry.AvailableAt,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultEntries,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetCustomerPoints(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "customer" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	customer := models.SelectCustomerByUserId(int(id))
+	if customer.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Customer not found",
+		})
+	}
+
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountPointEntriesByCustomerId(int(customer.ID))
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	entries := models.SelectPointEntriesByCustomerId(int(customer.ID), limit, offset)
+	resultEntries := make([]map[string]interface{}, len(entries))
+	for i, entry := range entries {
+		resultEntries[i] = map[string]interface{}{
+			"id":         entry.ID,
+			"created_at": entry.CreatedAt,
+			"type":       entry.Type,
+			"points":     entry.Points,
+			"reference":  entry.Reference,
+			"expires_at": entry.ExpiresAt,
+		}
+	}
+
+	now := time.Now()
+	expiringBefore := now.AddDate(0, 0, 30)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data": fiber.Map{
+			"balance":         models.SelectPointBalance(int(customer.ID)),
+			"point_value":     helpers.LoyaltyPointValue(),
+			"point_currency":  helpers.BaseCurrency(),
+			"expiring_points": models.SelectExpiringPoints(int(customer.ID), now, expiringBefore),
+			"expiring_before": expiringBefore,
+			"history":         resultEntries,
+		},
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
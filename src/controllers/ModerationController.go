@@ -0,0 +1,412 @@
+package controllers
+
+import (
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// canViewListing lets the owning seller and admins open listings that are
+// not public yet.
+func canViewListing(c *fiber.Ctx, product *models.Product) bool {
+	auth, ok := middlewares.OptionalUserLocals(c)
+	if !ok {
+		return false
+	}
+
+	role, _ := auth["role"].(string)
+	if role == "admin" {
+		return true
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok || role != "seller" {
+		return false
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	return seller.ID != 0 && seller.ID == product.SellerID
+}
+
+// productContentChanged reports whether an update touches what a moderator
+// reviewed. Price and stock are left out so sellers can keep those current
+// without losing their approval. Updates only carry the fields being set.
+func productContentChanged(existProduct, product *models.Product) bool {
+	return (product.Name != "" && product.Name != existProduct.Name) ||
+		(product.Description != "" && product.Description != existProduct.Description) ||
+		(product.Image != "" && product.Image != existProduct.Image) ||
+		(product.Color != "" && product.Color != existProduct.Color) ||
+		(product.Size != 0 && product.Size != existProduct.Size) ||
+		(product.Condition != "" && product.Condition != existProduct.Condition) ||
+		(product.CategoryID != 0 && product.CategoryID != existProduct.CategoryID)
+}
+
+// remoderateProduct sends an edited listing back through the pre-check when it
+// was rejected before, an approved listing's content changed, or the new
+// content contains banned keywords.
+func remoderateProduct(existProduct, product *models.Product) error {
+	if existProduct.Status != models.ListingRejected &&
+		!(existProduct.Status == models.ListingActive && productContentChanged(existProduct, product)) &&
+		len(helpers.FindBannedKeywords(product.Name, product.Description)) == 0 {
+		return nil
+	}
+
+	helpers.ModerateProduct(product)
+	return models.UpdateProductStatus(int(existProduct.ID), product.Status, product.ModerationNote, nil)
+}
+
+func GetSellerCatalog(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	status := models.ListingStatus(c.Query("status"))
+	sort := helpers.GetSortParams(c.Query("sorting"), c.Query("orderBy"))
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountSellerCatalog(int(seller.ID), status)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	products := models.SelectSellerCatalog(int(seller.ID), status, sort, limit, offset)
+	if len(products) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Product is empty",
+		})
+	}
+
+	resultProducts := make([]map[string]interface{}, len(products))
+	for i, product := range products {
+		resultProducts[i] = map[string]interface{}{
+			"id":              product.ID,
+			"created_at":      product.CreatedAt,
+			"updated_at":      product.UpdatedAt,
+			"name":            product.Name,
+			"price":           product.Price,
+			"stock":           product.Stock,
+			"photo":           product.Image,
+			"category":        product.Category.Name,
+			"listing":         product.Status,
+			"moderation_note": product.ModerationNote,
+			"reviewed_at":     product.ReviewedAt,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultProducts,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+type ListingStatusRequest struct {
+	Status models.ListingStatus `json:"status" validate:"required,oneof=draft pending_review archived"`
+}
+
+func UpdateProductListingStatus(c *fiber.Ctx) error {
+	var statusData ListingStatusRequest
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(userId))
+	product := models.SelectProductById(id)
+	if product.ID == 0 || seller.ID == 0 || product.SellerID != seller.ID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Product not found",
+		})
+	}
+
+	if err := c.BodyParser(&statusData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	if errors := helpers.StructValidation(&statusData); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	if statusData.Status == models.ListingPendingReview {
+		if product.Status == models.ListingActive || product.Status == models.ListingPendingReview {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":     "bad request",
+				"statusCode": 400,
+				"message":    fmt.Sprintf("Product is already %s", product.Status),
+			})
+		}
+
+		helpers.ModerateProduct(product)
+	} else {
+		product.Status = statusData.Status
+	}
+
+	if err := models.UpdateProductStatus(id, product.Status, product.ModerationNote, product.ReviewedAt); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to update listing of product with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Product with ID %d is now %s", id, product.Status),
+			"data": fiber.Map{
+				"listing":         product.Status,
+				"moderation_note": product.ModerationNote,
+			},
+		})
+	}
+}
+
+func GetModerationQueue(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	status := models.ListingStatus(c.Query("status"))
+	if status == "" {
+		status = models.ListingPendingReview
+	}
+
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountProductsByStatus(status)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	products := models.SelectProductsByStatus(status, limit, offset)
+	if len(products) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Moderation queue is empty",
+		})
+	}
+
+	resultProducts := make([]map[string]interface{}, len(products))
+	for i, product := range products {
+		resultProducts[i] = map[string]interface{}{
+			"id":              product.ID,
+			"created_at":      product.CreatedAt,
+			"updated_at":      product.UpdatedAt,
+			"seller_id":       product.SellerID,
+			"seller_name":     product.Seller.Name,
+			"name":            product.Name,
+			"price":           product.Price,
+			"photo":           product.Image,
+			"desc":            product.Description,
+			"category":        product.Category.Name,
+			"listing":         product.Status,
+			"moderation_note": product.ModerationNote,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultProducts,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+func ApproveProduct(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	product := models.SelectProductById(id)
+	if product.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Product not found",
+		})
+	}
+
+	if product.Status != models.ListingPendingReview {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Product is not pending review",
+		})
+	}
+
+	now := time.Now()
+	if err := models.UpdateProductStatus(id, models.ListingActive, "", &now); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to approve product with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Product with ID %d approved successfully", id),
+		})
+	}
+}
+
+type ProductRejection struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+func RejectProduct(c *fiber.Ctx) error {
+	var rejectionData ProductRejection
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	product := models.SelectProductById(id)
+	if product.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Product not found",
+		})
+	}
+
+	if product.Status != models.ListingPendingReview && product.Status != models.ListingActive {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Product is not pending review or active",
+		})
+	}
+
+	if err := c.BodyParser(&rejectionData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	rejection := middlewares.XSSMiddleware(&rejectionData).(*ProductRejection)
+	if errors := helpers.StructValidation(rejection); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	now := time.Now()
+	if err := models.UpdateProductStatus(id, models.ListingRejected, rejection.Reason, &now); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to reject product with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Product with ID %d rejected successfully", id),
+		})
+	}
+}
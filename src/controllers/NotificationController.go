@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"gofiber-marketplace/src/services"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func notificationResult(notification *models.Notification) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         notification.ID,
+		"created_at": notification.CreatedAt,
+		"type":       notification.Type,
+		"title":      notification.Title,
+		"message":    notification.Message,
+		"read_at":    notification.ReadAt,
+	}
+}
+
+func GetNotifications(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	unreadOnly := c.QueryBool("unread")
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountNotificationsByUserId(int(id), unreadOnly)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+	unreadCount := models.CountNotificationsByUserId(int(id), true)
+
+	notifications := models.SelectNotificationsByUserId(int(id), unreadOnly, limit, offset)
+	if len(notifications) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Notification is empty",
+		})
+	}
+
+	resultNotifications := make([]map[string]interface{}, len(notifications))
+	for i, notification := range notifications {
+		resultNotifications[i] = notificationResult(notification)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultNotifications,
+		"unreadCount": unreadCount,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+func ReadNotification(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	if notification := models.SelectNotificationById(id); notification.ID == 0 || notification.UserID != uint(userId) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Notification not found",
+		})
+	}
+
+	if err := models.MarkNotificationRead(id, time.Now()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to mark notification with ID %d as read", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Notification with ID %d marked as read", id),
+		})
+	}
+}
+
+func ReadAllNotifications(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	if count, err := models.MarkAllNotificationsRead(int(id), time.Now()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to mark notifications as read",
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("%d notifications marked as read", count),
+		})
+	}
+}
+
+// StreamNotifications keeps a Server-Sent Events stream open. It starts with
+// the unread count, then sends every new notification and a comment line every
+// 30 seconds so proxies do not drop an idle connection.
+func StreamNotifications(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	userId := uint(id)
+	unreadCount := models.CountNotificationsByUserId(int(id), true)
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		events, unsubscribe := services.DefaultBroker.Subscribe(userId)
+		defer unsubscribe()
+
+		fmt.Fprintf(w, "event: unread\ndata: %d\n\n", unreadCount)
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		keepAlive := time.NewTicker(30 * time.Second)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case notification := <-events:
+				data, err := json.Marshal(notificationResult(notification))
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "id: %d\nevent: notification\ndata: %s\n\n", notification.ID, data)
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": ping\n\n")
+			case <-services.DefaultBroker.Done():
+				return
+			}
+
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,778 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"gofiber-marketplace/src/services"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CheckoutShipping struct {
+	SellerID uint   `json:"seller_id" validate:"required"`
+	Provider string `json:"provider" validate:"required"`
+	Courier  string `json:"courier" validate:"required"`
+	Service  string `json:"service" validate:"required"`
+}
+
+type ShipOrderRequest struct {
+	Courier        string `json:"courier" validate:"required,max=50"`
+	TrackingNumber string `json:"tracking_number" validate:"required,max=50"`
+}
+
+type CheckoutRequest struct {
+	AddressID uint               `json:"address_id" validate:"required"`
+	Items     []CartItem         `json:"items" validate:"required,min=1,dive"`
+	Shipping  []CheckoutShipping `json:"shipping" validate:"required,min=1,dive"`
+	Coupon    string             `json:"coupon"`
+	Points    int                `json:"points" validate:"gte=0"`
+}
+
+func orderResult(order *models.Order) map[string]interface{} {
+	items := make([]map[string]interface{}, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = map[string]interface{}{
+			"id":         item.ID,
+			"product_id": item.ProductID,
+			"name":       item.Name,
+			"photo":      item.Image,
+			"quantity":   item.Quantity,
+			"unit_price": item.UnitPrice,
+			"amount":     item.Amount,
+			"discount":   item.Discount,
+			"tax_rate":   item.TaxRate,
+			"tax":        item.Tax,
+			"gross":      item.Gross,
+		}
+	}
+
+	return map[string]interface{}{
+		"id":            order.ID,
+		"created_at":    order.CreatedAt,
+		"number":        order.Number,
+		"status":        order.Status,
+		"customer_id":   order.CustomerID,
+		"customer_name": order.Customer.Name,
+		"seller_id":     order.SellerID,
+		"seller_name":   order.Seller.Name,
+		"currency":      order.Currency,
+		"subtotal":      order.Subtotal,
+		"discount":      order.Discount,
+		"tax":           order.Tax,
+		"shipping_cost": order.ShippingCost,
+		"total":         order.Total,
+		"shipping": map[string]interface{}{
+			"provider":       order.ShippingProvider,
+			"courier":        order.ShippingCourier,
+			"service":        order.ShippingService,
+			"recipient_name": order.RecipientName,
+			"phone":          order.RecipientPhone,
+			"main_address":   order.ShippingAddress,
+			"detail_address": order.ShippingDetail,
+			"city":           order.ShippingCity,
+			"postal_code":    order.ShippingPostalCode,
+		},
+		"items":        items,
+		"paid_at":      order.PaidAt,
+		"cancelled_at": order.CancelledAt,
+		"shipped_at":   order.ShippedAt,
+		"delivered_at": order.DeliveredAt,
+		"completed_at": order.CompletedAt,
+	}
+}
+
+// canViewOrder lets the buyer, the seller and admins see an order.
+func canViewOrder(role string, userId int, order *models.Order) bool {
+	switch role {
+	case "admin":
+		return true
+	case "customer":
+		return models.SelectCustomerByUserId(userId).ID == order.CustomerID
+	case "seller":
+		return models.SelectSellerByUserId(userId).ID == order.SellerID
+	}
+	return false
+}
+
+func Checkout(c *fiber.Ctx) error {
+	var checkoutData CheckoutRequest
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "customer" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	customer := models.SelectCustomerByUserId(int(id))
+	if customer.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Customer not found",
+		})
+	}
+
+	if err := c.BodyParser(&checkoutData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	if errors := helpers.StructValidation(&checkoutData); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	address := models.SelectAddressbyId(int(checkoutData.AddressID))
+	if address.ID == 0 || address.UserID != uint(id) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Address not found",
+		})
+	}
+
+	now := time.Now()
+	cart, cartErr := buildCart(checkoutData.Items, now)
+	if cartErr != nil {
+		return errorResponse(c, cartErr)
+	}
+
+	for _, item := range checkoutData.Items {
+		for _, line := range cart.Lines() {
+			if line.Product.ID == item.ProductID && item.QuotedPrice > 0 && line.UnitPrice != item.QuotedPrice {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"status":     "conflict",
+					"statusCode": 409,
+					"message":    fmt.Sprintf("Price of product with ID %d has changed", item.ProductID),
+					"data":       helpers.PromotionFields(line.Product, line.Promotion),
+				})
+			}
+		}
+	}
+
+	var coupon *models.Coupon
+	var couponDiscount models.Money
+	if checkoutData.Coupon != "" {
+		coupon = models.SelectCouponByCode(strings.ToUpper(checkoutData.Coupon))
+		if coupon.ID == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":     "not found",
+				"statusCode": 404,
+				"message":    "Coupon not found",
+			})
+		}
+
+		var couponErr *fiber.Error
+		if _, couponDiscount, couponErr = applyCoupon(cart, coupon, customer.ID, now); couponErr != nil {
+			return errorResponse(c, couponErr)
+		}
+	}
+
+	var pointsUsed int
+	var pointsDiscount models.Money
+	if checkoutData.Points > 0 {
+		var pointsErr *fiber.Error
+		if pointsUsed, pointsDiscount, pointsErr = applyPoints(cart, customer.ID, checkoutData.Points); pointsErr != nil {
+			return errorResponse(c, pointsErr)
+		}
+	}
+
+	for _, group := range cart.Groups {
+		var choice *CheckoutShipping
+		for i := range checkoutData.Shipping {
+			if checkoutData.Shipping[i].SellerID == group.Seller.ID {
+				choice = &checkoutData.Shipping[i]
+				break
+			}
+		}
+		if choice == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":     "bad request",
+				"statusCode": 400,
+				"message":    fmt.Sprintf("Shipping for seller with ID %d must be selected", group.Seller.ID),
+			})
+		}
+
+		options, err := services.QuoteShipping(services.ShippingRequest{
+			OriginCity:            group.Seller.Location,
+			DestinationCity:       address.City,
+			DestinationPostalCode: address.PostalCode,
+			WeightGrams:           group.WeightGrams,
+		})
+		var providerErr *services.ShippingProviderError
+		if errors.As(err, &providerErr) {
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+				"status":     "bad gateway",
+				"statusCode": 502,
+				"message":    fmt.Sprintf("Shipping provider %s failed", providerErr.Provider),
+			})
+		}
+
+		for i := range options {
+			if options[i].Provider == choice.Provider && options[i].Courier == choice.Courier && options[i].Service == choice.Service {
+				group.Shipping = &options[i]
+				break
+			}
+		}
+		if group.Shipping == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":     "bad request",
+				"statusCode": 400,
+				"message":    fmt.Sprintf("Shipping option %s %s is not available for seller with ID %d", choice.Courier, choice.Service, group.Seller.ID),
+			})
+		}
+
+		// Shipping is quoted in the provider's currency but charged in the
+		// cart's.
+		rate, err := services.DefaultExchangeRates.Rate(group.Shipping.Currency, cart.Currency)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":     "bad request",
+				"statusCode": 400,
+				"message":    fmt.Sprintf("Shipping cannot be charged in %s", cart.Currency),
+			})
+		}
+		group.ShippingCost = group.Shipping.Cost.Convert(rate)
+	}
+	cart.Calculate()
+
+	payment := models.Payment{
+		CustomerID: customer.ID,
+		Provider:   services.DefaultPaymentProvider.Name(),
+		Amount:     cart.Total,
+		Currency:   cart.Currency,
+	}
+	if coupon != nil {
+		payment.CouponID = &coupon.ID
+		payment.CouponDiscount = couponDiscount
+	}
+	payment.PointsUsed = pointsUsed
+	payment.PointsDiscount = pointsDiscount
+
+	orders := cart.Orders(customer.ID, address)
+	if err := models.PlaceOrders(&payment, orders); errors.Is(err, models.ErrInsufficientStock) || errors.Is(err, models.ErrProductUnavailable) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":     "conflict",
+			"statusCode": 409,
+			"message":    fmt.Sprintf("Checkout failed: %v", err),
+		})
+	} else if errors.Is(err, models.ErrPromotionNotActive) || errors.Is(err, models.ErrPromotionSoldOut) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":     "conflict",
+			"statusCode": 409,
+			"message":    fmt.Sprintf("Checkout failed, the sale price is no longer available: %v", err),
+		})
+	} else if errors.Is(err, models.ErrPromotionCustomerLimit) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    fmt.Sprintf("Checkout failed: %v", err),
+		})
+	} else if errors.Is(err, models.ErrInsufficientPoints) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Insufficient points",
+		})
+	} else if errors.Is(err, models.ErrCouponNotActive) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Coupon is not active",
+		})
+	} else if errors.Is(err, models.ErrCouponUsageLimit) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Coupon usage limit reached",
+		})
+	} else if errors.Is(err, models.ErrCouponCustomerLimit) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Coupon usage limit per customer reached",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to place order",
+		})
+	}
+
+	var reference string
+	if payment.Amount > 0 {
+		var err error
+		reference, err = services.DefaultPaymentProvider.Charge(services.ChargeRequest{
+			Amount:    payment.Amount,
+			Currency:  payment.Currency,
+			Reference: payment.Reference(),
+		})
+		if err != nil {
+			if err := models.FailPayment(payment.ID, err.Error(), time.Now()); err != nil {
+				log.Printf("Failed to cancel payment %d: %v", payment.ID, err)
+			}
+			return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
+				"status":     "payment required",
+				"statusCode": 402,
+				"message":    "Payment was declined",
+			})
+		}
+	}
+
+	if err := models.CompletePayment(payment.ID, reference, time.Now(), helpers.LedgerHoldPeriod()); err != nil {
+		log.Printf("Payment %d was charged as %q but could not be completed: %v", payment.ID, reference, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to complete payment",
+		})
+	}
+
+	placed := models.SelectPaymentById(int(payment.ID))
+	resultOrders := make([]map[string]interface{}, len(placed.Orders))
+	for i := range placed.Orders {
+		resultOrders[i] = orderResult(&placed.Orders[i])
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    "Order placed successfully",
+		"data": fiber.Map{
+			"payment_id":      placed.ID,
+			"status":          placed.Status,
+			"currency":        placed.Currency,
+			"amount":          placed.Amount,
+			"coupon_discount": placed.CouponDiscount,
+			"points_used":     placed.PointsUsed,
+			"points_discount": placed.PointsDiscount,
+			"paid_at":         placed.PaidAt,
+			"orders":          resultOrders,
+		},
+	})
+}
+
+func GetOrders(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	role := auth["role"].(string)
+	if role != "customer" && role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	status := c.Query("status")
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+
+	var orders []*models.Order
+	var totalData int64
+	if role == "customer" {
+		customer := models.SelectCustomerByUserId(int(id))
+		if customer.ID == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":     "not found",
+				"statusCode": 404,
+				"message":    "Customer not found",
+			})
+		}
+		totalData = models.CountOrdersByCustomerId(int(customer.ID), status)
+		orders = models.SelectOrdersByCustomerId(int(customer.ID), status, limit, offset)
+	} else {
+		seller := models.SelectSellerByUserId(int(id))
+		if seller.ID == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":     "not found",
+				"statusCode": 404,
+				"message":    "Seller not found",
+			})
+		}
+		totalData = models.CountOrdersBySellerId(int(seller.ID), status)
+		orders = models.SelectOrdersBySellerId(int(seller.ID), status, limit, offset)
+	}
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	if len(orders) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Order is empty",
+		})
+	}
+
+	resultOrders := make([]map[string]interface{}, len(orders))
+	for i, order := range orders {
+		resultOrders[i] = orderResult(order)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultOrders,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+func GetDetailOrder(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	order := models.SelectOrderById(id)
+	if order.ID == 0 || !canViewOrder(auth["role"].(string), int(userId), order) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Order not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data":       orderResult(order),
+	})
+}
+
+func ShipOrder(c *fiber.Ctx) error {
+	var shipData ShipOrderRequest
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	order := models.SelectOrderById(id)
+	if order.ID == 0 || !canViewOrder("seller", int(userId), order) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Order not found",
+		})
+	}
+
+	if err := c.BodyParser(&shipData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	if errors := helpers.StructValidation(&shipData); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	shipment := models.Shipment{
+		OrderID:        order.ID,
+		Courier:        strings.TrimSpace(shipData.Courier),
+		TrackingNumber: strings.TrimSpace(shipData.TrackingNumber),
+	}
+	if err := models.ShipOrder(&shipment, time.Now()); errors.Is(err, models.ErrOrderStatus) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":     "conflict",
+			"statusCode": 409,
+			"message":    fmt.Sprintf("Order with status %s cannot be shipped", order.Status),
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to ship order",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    "Order shipped successfully",
+		"data":       orderResult(models.SelectOrderById(id)),
+	})
+}
+
+func CompleteOrder(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "customer" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	order := models.SelectOrderById(id)
+	if order.ID == 0 || !canViewOrder("customer", int(userId), order) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Order not found",
+		})
+	}
+
+	if err := models.CompleteOrder(order.ID, time.Now()); errors.Is(err, models.ErrOrderStatus) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":     "conflict",
+			"statusCode": 409,
+			"message":    "Only delivered orders can be completed",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to complete order",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    "Order completed successfully",
+		"data":       orderResult(models.SelectOrderById(id)),
+	})
+}
+
+func GetOrderTimeline(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	order := models.SelectOrderById(id)
+	if order.ID == 0 || !canViewOrder(auth["role"].(string), int(userId), order) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Order not found",
+		})
+	}
+
+	shipments := models.SelectShipmentsByOrderId(id)
+	resultShipments := make([]map[string]interface{}, len(shipments))
+	for i, shipment := range shipments {
+		resultShipments[i] = map[string]interface{}{
+			"id":                shipment.ID,
+			"return_request_id": shipment.ReturnRequestID,
+			"courier":           shipment.Courier,
+			"tracking_number":   shipment.TrackingNumber,
+			"status":            shipment.Status,
+			"delivered_at":      shipment.DeliveredAt,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data": fiber.Map{
+			"order_id":  order.ID,
+			"number":    order.Number,
+			"status":    order.Status,
+			"shipments": resultShipments,
+			"events":    helpers.OrderTimeline(order, shipments),
+		},
+	})
+}
+
+// GetOrderInvoice downloads the invoice issued when the order was paid.
+func GetOrderInvoice(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	order := models.SelectOrderById(id)
+	if order.ID == 0 || !canViewOrder(auth["role"].(string), int(userId), order) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Order not found",
+		})
+	}
+
+	invoice := models.SelectInvoiceByOrderId(id)
+	if invoice.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Invoice is only available for paid orders",
+		})
+	}
+
+	var buffer bytes.Buffer
+	if err := helpers.WriteInvoicePDF(&buffer, invoice, order); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to generate invoice",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.pdf"`, invoice.Number))
+	return c.Status(fiber.StatusOK).Send(buffer.Bytes())
+}
+
+// handleOrderStatus tells the seller about new paid orders and the customer
+// about how their order is progressing. Completed orders also earn the
+// customer their loyalty points.
+func handleOrderStatus(ctx context.Context, payload []byte) error {
+	var data models.OrderStatusPayload
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return err
+	}
+
+	order := models.SelectOrderById(int(data.OrderID))
+	if order.ID == 0 {
+		return nil
+	}
+
+	switch data.Status {
+	case models.OrderPaid:
+		message := fmt.Sprintf("Order %s from %s is paid and ready to ship", order.Number, order.Customer.Name)
+		return services.DefaultNotifier.Notify(order.Seller.UserID, models.NotificationOrderStatus, "New order", message)
+	case models.OrderShipped:
+		message := fmt.Sprintf("%s shipped order %s", order.Seller.Name, order.Number)
+		return services.DefaultNotifier.Notify(order.Customer.UserID, models.NotificationOrderStatus, "Order shipped", message)
+	case models.OrderDelivered:
+		message := fmt.Sprintf("Order %s was delivered", order.Number)
+		return services.DefaultNotifier.Notify(order.Customer.UserID, models.NotificationOrderStatus, "Order delivered", message)
+	case models.OrderCompleted:
+		if err := helpers.EarnOrderPoints(order, time.Now()); err != nil {
+			return err
+		}
+		message := fmt.Sprintf("Order %s is completed", order.Number)
+		return services.DefaultNotifier.Notify(order.Seller.UserID, models.NotificationOrderStatus, "Order completed", message)
+	}
+	return nil
+}
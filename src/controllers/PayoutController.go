@@ -0,0 +1,329 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetSellerPayouts(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountPayoutsBySellerId(int(seller.ID))
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	payouts := models.SelectPayoutsBySellerId(int(seller.ID), limit, offset)
+	if len(payouts) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Payout is empty",
+		})
+	}
+
+	resultPayouts := make([]map[string]interface{}, len(payouts))
+	for i, payout := range payouts {
+		resultPayouts[i] = map[string]interface{}{
+			"id":           payout.ID,
+			"created_at":   payout.CreatedAt,
+			"amount":       payout.Amount,
+			"currency":     payout.Currency,
+			"status":       payout.Status,
+			"note":         payout.Note,
+			"processed_at": payout.ProcessedAt,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultPayouts,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+type PayoutRequest struct {
+	Amount models.Money `json:"amount" validate:"required,gt=0"`
+}
+
+func CreatePayout(c *fiber.Ctx) error {
+	var payoutData PayoutRequest
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	if err := c.BodyParser(&payoutData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	if errors := helpers.StructValidation(&payoutData); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	newPayout := models.Payout{
+		SellerID: seller.ID,
+		Amount:   payoutData.Amount,
+	}
+
+	if err := models.CreatePayout(&newPayout); errors.Is(err, models.ErrInsufficientBalance) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Insufficient available balance",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to request payout",
+		})
+	} else {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    "Payout requested successfully",
+		})
+	}
+}
+
+func GetPayouts(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	status := models.PayoutStatus(c.Query("status"))
+	if status == "" {
+		status = models.PayoutPending
+	}
+
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountPayoutsByStatus(status)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	payouts := models.SelectPayoutsByStatus(status, limit, offset)
+	if len(payouts) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Payout is empty",
+		})
+	}
+
+	resultPayouts := make([]map[string]interface{}, len(payouts))
+	for i, payout := range payouts {
+		resultPayouts[i] = map[string]interface{}{
+			"id":           payout.ID,
+			"created_at":   payout.CreatedAt,
+			"seller_id":    payout.SellerID,
+			"seller_name":  payout.Seller.Name,
+			"amount":       payout.Amount,
+			"currency":     payout.Currency,
+			"status":       payout.Status,
+			"note":         payout.Note,
+			"processed_at": payout.ProcessedAt,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultPayouts,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+func ApprovePayout(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	if payout := models.SelectPayoutById(id); payout.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Payout not found",
+		})
+	}
+
+	if err := models.ApprovePayout(id); errors.Is(err, models.ErrPayoutProcessed) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Payout already processed",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to approve payout with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Payout with ID %d approved successfully", id),
+		})
+	}
+}
+
+type PayoutRejection struct {
+	Note string `json:"note" validate:"required,max=255"`
+}
+
+func RejectPayout(c *fiber.Ctx) error {
+	var rejectionData PayoutRejection
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	if payout := models.SelectPayoutById(id); payout.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Payout not found",
+		})
+	}
+
+	if err := c.BodyParser(&rejectionData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	rejection := middlewares.XSSMiddleware(&rejectionData).(*PayoutRejection)
+	if errors := helpers.StructValidation(rejection); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	if err := models.RejectPayout(id, rejection.Note); errors.Is(err, models.ErrPayoutProcessed) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Payout already processed",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to reject payout with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Payout with ID %d rejected successfully", id),
+		})
+	}
+}
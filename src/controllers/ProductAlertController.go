@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"gofiber-marketplace/src/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetProductAlerts(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "customer" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	customer := models.SelectCustomerByUserId(int(id))
+	if customer.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Customer not found",
+		})
+	}
+
+	alerts := models.SelectProductAlertsByCustomerId(int(customer.ID))
+	if len(alerts) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Alert is empty",
+		})
+	}
+
+	resultAlerts := make([]map[string]interface{}, len(alerts))
+	for i, alert := range alerts {
+		resultAlerts[i] = map[string]interface{}{
+			"id":           alert.ID,
+			"created_at":   alert.CreatedAt,
+			"product_id":   alert.ProductID,
+			"product_name": alert.Product.Name,
+			"price":        alert.Product.Price,
+			"stock":        alert.Product.Stock,
+			"type":         alert.Type,
+			"target_price": alert.TargetPrice,
+			"notified_at":  alert.NotifiedAt,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data":       resultAlerts,
+	})
+}
+
+type ProductAlertRequest struct {
+	ProductID   uint                    `json:"product_id" validate:"required"`
+	Type        models.ProductAlertType `json:"type" validate:"required,oneof=back_in_stock price_drop"`
+	TargetPrice models.Money            `json:"target_price" validate:"required_if=Type price_drop,gte=0"`
+}
+
+func CreateProductAlert(c *fiber.Ctx) error {
+	var alertData ProductAlertRequest
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "customer" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	customer := models.SelectCustomerByUserId(int(id))
+	if customer.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Customer not found",
+		})
+	}
+
+	if err := c.BodyParser(&alertData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	alert := middlewares.XSSMiddleware(&alertData).(*ProductAlertRequest)
+	if errors := helpers.StructValidation(alert); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	product := models.SelectProductById(int(alert.ProductID))
+	if product.ID == 0 || product.Status != models.ListingActive {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Product not found",
+		})
+	}
+
+	if alert.Type == models.BackInStock && product.Stock > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Product is already in stock",
+		})
+	}
+
+	if alert.Type == models.PriceDrop && product.Price <= alert.TargetPrice {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Product price is already below target price",
+		})
+	}
+
+	if existAlert := models.SelectPendingProductAlert(int(customer.ID), int(product.ID), alert.Type); existAlert.ID != 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Alert already exists",
+		})
+	}
+
+	newAlert := models.ProductAlert{
+		CustomerID:  customer.ID,
+		ProductID:   product.ID,
+		Type:        alert.Type,
+		TargetPrice: alert.TargetPrice,
+	}
+
+	if err := models.CreateProductAlert(&newAlert); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to create alert",
+		})
+	} else {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    "Alert created successfully",
+		})
+	}
+}
+
+func DeleteProductAlert(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "customer" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	customer := models.SelectCustomerByUserId(int(userId))
+	alert := models.SelectProductAlertById(id)
+	if alert.ID == 0 || alert.CustomerID != customer.ID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Alert not found",
+		})
+	}
+
+	if err := models.DeleteProductAlert(id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to delete alert with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Alert with ID %d deleted successfully", id),
+		})
+	}
+}
+
+// notifyProductAlerts notifies every pending alert the change satisfies. An
+// alert that fails is left pending and reported, so a retry only picks up the
+// ones that did not go out.
+func notifyProductAlerts(before, after *models.Product) error {
+	restocked := before.Stock <= 0 && after.Stock > 0
+	priceDropped := after.Price < before.Price
+	if !restocked && !priceDropped {
+		return nil
+	}
+
+	var failed []error
+
+	for _, alert := range models.SelectPendingProductAlertsByProductId(int(after.ID)) {
+		var title, message string
+		switch {
+		case alert.Type == models.BackInStock && restocked:
+			title = "Back in stock"
+			message = fmt.Sprintf("%s is back in stock", after.Name)
+		case alert.Type == models.PriceDrop && priceDropped && after.Price < alert.TargetPrice:
+			title = "Price drop"
+			message = fmt.Sprintf("%s is now %s", after.Name, after.Price)
+		default:
+			continue
+		}
+
+		if err := services.DefaultNotifier.Notify(alert.Customer.UserID, models.NotificationProductAlert, title, message); err != nil {
+			failed = append(failed, fmt.Errorf("notify product alert %d: %w", alert.ID, err))
+			continue
+		}
+
+		if err := models.MarkProductAlertNotified(int(alert.ID)); err != nil {
+			failed = append(failed, fmt.Errorf("mark product alert %d: %w", alert.ID, err))
+		}
+	}
+	return errors.Join(failed...)
+}
@@ -1,17 +1,29 @@
 package controllers
 
 import (
+	"encoding/json"
 	"fmt"
 	"gofiber-marketplace/src/helpers"
 	"gofiber-marketplace/src/middlewares"
 	"gofiber-marketplace/src/models"
+	"log"
 	"math"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func GetAllProduct(c *fiber.Ctx) error {
+	currency := helpers.DisplayCurrency(c.Query("currency"), c.Get("Accept-Currency"))
+	if currency != "" && !helpers.SupportedCurrency(currency) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    fmt.Sprintf("Currency %s is not supported", currency),
+		})
+	}
+
 	keyword := c.Query("search")
 	sort := helpers.GetSortParams(c.Query("sorting"), c.Query("orderBy"))
 	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
@@ -27,6 +39,13 @@ func GetAllProduct(c *fiber.Ctx) error {
 		})
 	}
 
+	productIds := make([]uint, len(products))
+	for i, product := range products {
+		productIds[i] = product.ID
+	}
+	now := time.Now()
+	promotions := models.SelectActivePromotionsByProductIds(productIds, now)
+
 	resultProducts := make([]*map[string]interface{}, len(products))
 	for i, product := range products {
 		resultProducts[i] = &map[string]interface{}{
@@ -46,6 +65,10 @@ func GetAllProduct(c *fiber.Ctx) error {
 			"stock":         product.Stock,
 			"condition":     product.Condition,
 			"desc":          product.Description,
+			"available":     product.Stock > 0 && !helpers.IsOnVacation(&product.Seller, now),
+		}
+		for key, value := range helpers.DisplayPriceFields(product, helpers.PromotionFields(product, promotions[product.ID]), currency) {
+			(*resultProducts[i])[key] = value
 		}
 	}
 
@@ -71,8 +94,17 @@ func GetDetailProduct(c *fiber.Ctx) error {
 		})
 	}
 
+	currency := helpers.DisplayCurrency(c.Query("currency"), c.Get("Accept-Currency"))
+	if currency != "" && !helpers.SupportedCurrency(currency) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    fmt.Sprintf("Currency %s is not supported", currency),
+		})
+	}
+
 	product := models.SelectProductById(id)
-	if product.ID == 0 {
+	if product.ID == 0 || (product.Status != models.ListingActive && !canViewListing(c, product)) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"status":     "not found",
 			"statusCode": 404,
@@ -97,7 +129,47 @@ func GetDetailProduct(c *fiber.Ctx) error {
 		"stock":         product.Stock,
 		"condition":     product.Condition,
 		"desc":          product.Description,
+		"listing":       product.Status,
+		"weight":        product.Weight,
+		"length":        product.Length,
+		"width":         product.Width,
+		"height":        product.Height,
+	}
+
+	now := time.Now()
+	if product.Status == models.ListingActive {
+		if err := models.RecordProductView(product.ID, now); err != nil {
+			log.Printf("Failed to record view of product %d: %v", product.ID, err)
+		}
+	}
+
+	onVacation := helpers.IsOnVacation(&product.Seller, now)
+	resultProduct["available"] = product.Stock > 0 && !onVacation
+	if onVacation {
+		resultProduct["vacation_until"] = product.Seller.VacationUntil
+		resultProduct["vacation_note"] = product.Seller.VacationNote
+	}
+
+	for key, value := range helpers.DisplayPriceFields(product, helpers.PromotionFields(product, models.SelectActivePromotionByProductId(id, now)), currency) {
+		resultProduct[key] = value
+	}
+
+	wishlisted := false
+	if auth, ok := middlewares.OptionalUserLocals(c); ok && auth["role"] == "customer" {
+		if userId, ok := auth["id"].(float64); ok {
+			if customer := models.SelectCustomerByUserId(int(userId)); customer.ID != 0 {
+				wishlisted = models.SelectWishlist(int(customer.ID), id).ID != 0
+			}
+		}
+	}
+	resultProduct["wishlisted"] = wishlisted
+	resultProduct["wishlist_count"] = models.CountWishlistsByProductId(id)
+
+	lowestPrice := models.SelectLowestPriceSince(id, time.Now().AddDate(0, 0, -30))
+	if lowestPrice == 0 || lowestPrice > product.Price {
+		lowestPrice = product.Price
 	}
+	resultProduct["lowest_price_30_days"] = lowestPrice
 
 	// return c.JSON(product)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -107,6 +179,57 @@ func GetDetailProduct(c *fiber.Ctx) error {
 	})
 }
 
+func GetProductPriceHistory(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	if product := models.SelectProductById(id); product.ID == 0 || product.Status != models.ListingActive {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Product not found",
+		})
+	}
+
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountPriceHistoriesByProductId(id)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	histories := models.SelectPriceHistoriesByProductId(id, limit, offset)
+	if len(histories) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Price history is empty",
+		})
+	}
+
+	resultHistories := make([]map[string]interface{}, len(histories))
+	for i, history := range histories {
+		resultHistories[i] = map[string]interface{}{
+			"id":         history.ID,
+			"price":      history.Price,
+			"changed_at": history.CreatedAt,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultHistories,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
 func CreateProduct(c *fiber.Ctx) error {
 	auth := middlewares.UserLocals(c)
 	if role := auth["role"].(string); role != "seller" {
@@ -146,7 +269,8 @@ func CreateProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	if seller := models.SelectSellerById(int(product.SellerID)); seller.ID == 0 {
+	seller := models.SelectSellerById(int(product.SellerID))
+	if seller.ID == 0 {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"status":     "not found",
 			"statusCode": 404,
@@ -154,6 +278,20 @@ func CreateProduct(c *fiber.Ctx) error {
 		})
 	}
 
+	// Sales are booked on the seller's ledger, which is kept in one currency.
+	if product.Currency == "" {
+		product.Currency = seller.Currency
+	}
+	if product.Currency != seller.Currency {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    fmt.Sprintf("Products must be listed in the seller's currency %s", seller.Currency),
+		})
+	}
+
+	helpers.ModerateProduct(product)
+
 	if err := models.CreateProduct(product); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":     "server error",
@@ -165,6 +303,11 @@ func CreateProduct(c *fiber.Ctx) error {
 			"status":     "success",
 			"statusCode": 200,
 			"message":    "Product created successfully",
+			"data": fiber.Map{
+				"id":              product.ID,
+				"listing":         product.Status,
+				"moderation_note": product.ModerationNote,
+			},
 		})
 	}
 }
@@ -188,7 +331,8 @@ func UpdateProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	if product := models.SelectProductById(id); product.ID == 0 {
+	existProduct := models.SelectProductById(id)
+	if existProduct.ID == 0 {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"status":     "not found",
 			"statusCode": 404,
@@ -207,6 +351,9 @@ func UpdateProduct(c *fiber.Ctx) error {
 	}
 
 	product := middlewares.XSSMiddleware(&updatedProduct).(*models.Product)
+	product.Status = ""
+	product.ModerationNote = ""
+	product.ReviewedAt = nil
 
 	if errors := helpers.StructValidation(product); len(errors) > 0 {
 		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
@@ -233,12 +380,28 @@ func UpdateProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := models.UpdateProduct(id, product); err != nil {
+	// Prices, promotions, alerts and price history are all in the listing's
+	// currency, so switching it would silently reprice all of them.
+	if product.Currency != "" && product.Currency != existProduct.Currency {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Currency of an existing product cannot be changed",
+		})
+	}
+
+	if err := models.UpdateProduct(id, product, bodyHasField(c, "stock")); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":     "server error",
 			"statusCode": 500,
 			"message":    fmt.Sprintf("Failed to update product with ID %d", id),
 		})
+	} else if err := remoderateProduct(existProduct, product); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to update listing of product with ID %d", id),
+		})
 	} else {
 		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 			"status":     "success",
@@ -248,6 +411,23 @@ func UpdateProduct(c *fiber.Ctx) error {
 	}
 }
 
+// bodyHasField reports whether the request body sent field, so a zero value
+// can be told apart from a field that was left out.
+func bodyHasField(c *fiber.Ctx, field string) bool {
+	if c.Is("json") {
+		var body map[string]json.RawMessage
+		json.Unmarshal(c.Body(), &body)
+		_, ok := body[field]
+		return ok
+	}
+
+	if form, err := c.MultipartForm(); err == nil {
+		_, ok := form.Value[field]
+		return ok
+	}
+	return c.Request().PostArgs().Has(field)
+}
+
 func DeleteProduct(c *fiber.Ctx) error {
 	auth := middlewares.UserLocals(c)
 	if role := auth["role"].(string); role != "seller" {
@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ImportProducts(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Failed to upload file",
+		})
+	}
+
+	maxFileSize := int64(2 << 20) // 2MB
+	if err := helpers.SizeUploadValidation(file.Size, maxFileSize); err != nil {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"status":     "entity too large",
+			"statusCode": 413,
+			"message":    err.Error(),
+		})
+	}
+
+	if strings.ToLower(filepath.Ext(file.Filename)) != ".csv" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Type of file is invalid; only csv",
+		})
+	}
+
+	fileHeader, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to open file",
+		})
+	}
+	defer fileHeader.Close()
+
+	reader := csv.NewReader(fileHeader)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil || len(records) < 2 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "File must contain a header and at least one product row",
+		})
+	}
+
+	columns, missing := helpers.ProductCSVColumns(records[0])
+	if len(missing) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    fmt.Sprintf("File is missing columns: %s", strings.Join(missing, ", ")),
+		})
+	}
+
+	productImport := models.ProductImport{
+		SellerID:  seller.ID,
+		FileName:  file.Filename,
+		Status:    models.ImportPending,
+		TotalRows: len(records) - 1,
+	}
+
+	if err := models.CreateProductImport(&productImport, columns, records[1:]); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to create import",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":     "accepted",
+		"statusCode": 202,
+		"message":    "Import started",
+		"data": fiber.Map{
+			"id":         productImport.ID,
+			"status":     productImport.Status,
+			"total_rows": productImport.TotalRows,
+		},
+	})
+}
+
+func GetProductImport(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(userId))
+	productImport := models.SelectProductImportById(id)
+	if productImport.ID == 0 || productImport.SellerID != seller.ID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Import not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data":       productImport,
+	})
+}
+
+func ExportProducts(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	records := [][]string{helpers.ProductCSVHeader}
+	for _, product := range models.SelectAllProductsBySellerId(int(seller.ID)) {
+		records = append(records, helpers.ProductCSVRecord(product))
+	}
+
+	if err := writer.WriteAll(records); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to export products",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="products_%s.csv"`, time.Now().Format("20060102")))
+	return c.Status(fiber.StatusOK).Send(buffer.Bytes())
+}
+
+func handleProductImport(ctx context.Context, payload []byte) error {
+	var data models.ProductImportPayload
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return err
+	}
+
+	productImport := models.SelectProductImportById(int(data.ImportID))
+	switch productImport.Status {
+	case models.ImportPending:
+		runProductImport(productImport, data.Columns, data.Records)
+	case models.ImportProcessing:
+		// A worker died mid-import. Rows may already exist, so running the
+		// file again could create them twice.
+		productImport.Status = models.ImportFailed
+		return models.SaveProductImport(productImport)
+	}
+	return nil
+}
+
+func runProductImport(productImport *models.ProductImport, columns map[string]int, records [][]string) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Product import %d failed: %v", productImport.ID, r)
+			productImport.Status = models.ImportFailed
+			if err := models.SaveProductImport(productImport); err != nil {
+				log.Printf("Failed to save product import %d: %v", productImport.ID, err)
+			}
+		}
+	}()
+
+	productImport.Status = models.ImportProcessing
+	if err := models.SaveProductImport(productImport); err != nil {
+		log.Printf("Failed to save product import %d: %v", productImport.ID, err)
+	}
+
+	currency := models.SelectSellerById(int(productImport.SellerID)).Currency
+	categories := make(map[string]*models.Category)
+	for i, record := range records {
+		product, id, slug, errors := helpers.ParseProductCSVRecord(columns, record)
+		product.SellerID = productImport.SellerID
+
+		if slug != "" {
+			category, ok := categories[slug]
+			if !ok {
+				category = models.SelectCategoryBySlug(slug)
+				categories[slug] = category
+			}
+			if category.ID == 0 {
+				errors = append(errors, "category must contain existing category slug")
+			}
+			product.CategoryID = category.ID
+		}
+
+		if product.Currency != "" && product.Currency != currency {
+			errors = append(errors, "currency must contain the seller's currency")
+		}
+
+		product = middlewares.XSSMiddleware(product).(*models.Product)
+		for _, err := range helpers.StructValidation(product) {
+			errors = append(errors, err.ErrorMessage)
+		}
+
+		product.Status = ""
+		var existProduct *models.Product
+		if id != 0 {
+			existProduct = models.SelectProductById(id)
+			if existProduct.ID == 0 || existProduct.SellerID != productImport.SellerID {
+				errors = append(errors, "id must contain existing product of this seller")
+			} else if product.Currency != "" && product.Currency != existProduct.Currency {
+				errors = append(errors, "currency must contain the product's current currency")
+			}
+		}
+
+		if len(errors) == 0 {
+			if existProduct != nil {
+				// The stock column is required, so every row sends a stock.
+				if err := models.UpdateProduct(id, product, true); err != nil {
+					errors = append(errors, "failed to update product")
+				} else if err := remoderateProduct(existProduct, product); err != nil {
+					errors = append(errors, "failed to update product listing")
+				} else {
+					productImport.UpdatedRows++
+				}
+			} else {
+				if product.Currency == "" {
+					product.Currency = currency
+				}
+				helpers.ModerateProduct(product)
+				if err := models.CreateProduct(product); err != nil {
+					errors = append(errors, "failed to create product")
+				} else {
+					productImport.CreatedRows++
+				}
+			}
+		}
+
+		if len(errors) > 0 {
+			productImport.FailedRows++
+			productImport.RowErrors = append(productImport.RowErrors, models.ProductImportRowError{
+				Row:    i + 2,
+				Errors: errors,
+			})
+		}
+	}
+
+	productImport.Status = models.ImportCompleted
+	if err := models.SaveProductImport(productImport); err != nil {
+		log.Printf("Failed to save product import %d: %v", productImport.ID, err)
+	}
+}
@@ -0,0 +1,389 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"gofiber-marketplace/src/services"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetProductQuestions(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	if product := models.SelectProductById(id); product.ID == 0 || product.Status != models.ListingActive {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Product not found",
+		})
+	}
+
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountQuestionsByProductId(id)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	questions := models.SelectQuestionsByProductId(id, limit, offset)
+	if len(questions) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Question is empty",
+		})
+	}
+
+	resultQuestions := make([]map[string]interface{}, len(questions))
+	for i, question := range questions {
+		resultAnswers := make([]map[string]interface{}, len(question.Answers))
+		for j, answer := range question.Answers {
+			resultAnswers[j] = map[string]interface{}{
+				"id":          answer.ID,
+				"created_at":  answer.CreatedAt,
+				"seller_id":   answer.SellerID,
+				"seller_name": answer.Seller.Name,
+				"answer":      answer.Answer,
+				"upvotes":     answer.Upvotes,
+			}
+		}
+
+		resultQuestions[i] = map[string]interface{}{
+			"id":            question.ID,
+			"created_at":    question.CreatedAt,
+			"customer_name": question.Customer.Name,
+			"question":      question.Question,
+			"upvotes":       question.Upvotes,
+			"answers":       resultAnswers,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultQuestions,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+type QuestionRequest struct {
+	Question string `json:"question" validate:"required,max=500"`
+}
+
+func CreateProductQuestion(c *fiber.Ctx) error {
+	var questionData QuestionRequest
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "customer" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	customer := models.SelectCustomerByUserId(int(userId))
+	if customer.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Customer not found",
+		})
+	}
+
+	if product := models.SelectProductById(id); product.ID == 0 || product.Status != models.ListingActive {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Product not found",
+		})
+	}
+
+	if err := c.BodyParser(&questionData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	question := middlewares.XSSMiddleware(&questionData).(*QuestionRequest)
+	if errors := helpers.StructValidation(question); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	newQuestion := models.ProductQuestion{
+		ProductID:  uint(id),
+		CustomerID: customer.ID,
+		Question:   question.Question,
+	}
+
+	if err := models.CreateQuestion(&newQuestion); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to create question",
+		})
+	} else {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    "Question created successfully",
+		})
+	}
+}
+
+type AnswerRequest struct {
+	Answer string `json:"answer" validate:"required,max=1000"`
+}
+
+func CreateProductAnswer(c *fiber.Ctx) error {
+	var answerData AnswerRequest
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(userId))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	question := models.SelectQuestionById(id)
+	if question.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Question not found",
+		})
+	}
+
+	if question.Product.SellerID != seller.ID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Only the seller of this product can answer",
+		})
+	}
+
+	if err := c.BodyParser(&answerData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	answer := middlewares.XSSMiddleware(&answerData).(*AnswerRequest)
+	if errors := helpers.StructValidation(answer); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	newAnswer := models.ProductAnswer{
+		QuestionID: question.ID,
+		SellerID:   seller.ID,
+		Answer:     answer.Answer,
+	}
+
+	if err := models.CreateAnswer(&newAnswer); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to create answer",
+		})
+	} else {
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    "Answer created successfully",
+		})
+	}
+}
+
+func handleQuestionAnswered(ctx context.Context, payload []byte) error {
+	var data models.QuestionAnsweredPayload
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return err
+	}
+
+	answer := models.SelectAnswerById(int(data.AnswerID))
+	if answer.ID == 0 {
+		return nil
+	}
+	question := models.SelectQuestionById(int(answer.QuestionID))
+	seller := models.SelectSellerById(int(answer.SellerID))
+	if question.ID == 0 || seller.ID == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("%s answered your question about %s", seller.Name, question.Product.Name)
+	return services.DefaultNotifier.Notify(question.Customer.UserID, models.NotificationQuestionAnswered, "Question answered", message)
+}
+
+func UpvoteProductQuestion(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	if question := models.SelectQuestionById(id); question.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Question not found",
+		})
+	}
+
+	if err := models.UpvoteQuestion(uint(id), uint(userId)); errors.Is(err, models.ErrAlreadyUpvoted) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Question already upvoted",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to upvote question with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Question with ID %d upvoted", id),
+		})
+	}
+}
+
+func UpvoteProductAnswer(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	if answer := models.SelectAnswerById(id); answer.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Answer not found",
+		})
+	}
+
+	if err := models.UpvoteAnswer(uint(id), uint(userId)); errors.Is(err, models.ErrAlreadyUpvoted) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Answer already upvoted",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to upvote answer with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Answer with ID %d upvoted", id),
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"errors"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetProductReviews(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	if product := models.SelectProductById(id); product.ID == 0 || product.Status != models.ListingActive {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Product not found",
+		})
+	}
+
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountReviewsByProductId(id)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	reviews := models.SelectReviewsByProductId(id, limit, offset)
+	if len(reviews) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Review is empty",
+		})
+	}
+
+	resultReviews := make([]map[string]interface{}, len(reviews))
+	for i, review := range reviews {
+		resultReviews[i] = map[string]interface{}{
+			"id":            review.ID,
+			"created_at":    review.CreatedAt,
+			"customer_name": review.Customer.Name,
+			"rating":        review.Rating,
+			"comment":       review.Comment,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultReviews,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+type ReviewRequest struct {
+	Rating  uint   `json:"rating" validate:"required,min=1,max=5"`
+	Comment string `json:"comment" validate:"max=1000"`
+}
+
+func CreateProductReview(c *fiber.Ctx) error {
+	var reviewData ReviewRequest
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "customer" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	customer := models.SelectCustomerByUserId(int(userId))
+	if customer.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Customer not found",
+		})
+	}
+
+	if product := models.SelectProductById(id); product.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Product not found",
+		})
+	}
+
+	if err := c.BodyParser(&reviewData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	review := middlewares.XSSMiddleware(&reviewData).(*ReviewRequest)
+	if errors := helpers.StructValidation(review); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	newReview := models.ProductReview{
+		ProductID:  uint(id),
+		CustomerID: customer.ID,
+		Rating:     review.Rating,
+		Comment:    review.Comment,
+	}
+
+	points := helpers.LoyaltyReviewPoints()
+	if err := models.CreateReview(&newReview, points, time.Now().Add(helpers.LoyaltyExpiry())); errors.Is(err, models.ErrReviewNotAllowed) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Only customers with a completed order of this product can review it",
+		})
+	} else if errors.Is(err, models.ErrAlreadyReviewed) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":     "conflict",
+			"statusCode": 409,
+			"message":    "Product already reviewed",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to create review",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    "Review created successfully",
+		"data": fiber.Map{
+			"id":            newReview.ID,
+			"order_id":      newReview.OrderID,
+			"rating":        newReview.Rating,
+			"comment":       newReview.Comment,
+			"points_earned": points,
+		},
+	})
+}
@@ -0,0 +1,554 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"gofiber-marketplace/src/services"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type ReturnItemRequest struct {
+	OrderItemID uint `json:"order_item_id" validate:"required"`
+	Quantity    int  `json:"quantity" validate:"required,gt=0"`
+}
+
+type CreateReturnRequestData struct {
+	Reason string              `json:"reason" validate:"required,max=500"`
+	Photos []string            `json:"photos" validate:"max=5,dive,url"`
+	Items  []ReturnItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+type ReturnRejection struct {
+	Note string `json:"note" validate:"required,max=255"`
+}
+
+type ReceiveReturnRequest struct {
+	// RefundAmount is left at zero to refund everything the customer paid
+	// for the returned items.
+	RefundAmount models.Money `json:"refund_amount" validate:"gte=0"`
+}
+
+func returnResult(returnRequest *models.ReturnRequest) map[string]interface{} {
+	items := make([]map[string]interface{}, len(returnRequest.Items))
+	for i, item := range returnRequest.Items {
+		items[i] = map[string]interface{}{
+			"order_item_id": item.OrderItemID,
+			"product_id":    item.ProductID,
+			"quantity":      item.Quantity,
+			"amount":        item.Amount,
+		}
+	}
+
+	return map[string]interface{}{
+		"id":            returnRequest.ID,
+		"created_at":    returnRequest.CreatedAt,
+		"order_id":      returnRequest.OrderID,
+		"order_number":  returnRequest.Order.Number,
+		"customer_id":   returnRequest.CustomerID,
+		"seller_id":     returnRequest.SellerID,
+		"reason":        returnRequest.Reason,
+		"photos":        returnRequest.Photos,
+		"status":        returnRequest.Status,
+		"note":          returnRequest.Note,
+		"currency":      returnRequest.Currency,
+		"amount":        returnRequest.Amount,
+		"refund_amount": returnRequest.RefundAmount,
+		"items":         items,
+		"reviewed_at":   returnRequest.ReviewedAt,
+		"received_at":   returnRequest.ReceivedAt,
+		"refunded_at":   returnRequest.RefundedAt,
+	}
+}
+
+func CreateReturnRequest(c *fiber.Ctx) error {
+	var returnData CreateReturnRequestData
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "customer" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	order := models.SelectOrderById(id)
+	if order.ID == 0 || !canViewOrder("customer", int(userId), order) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Order not found",
+		})
+	}
+
+	if err := c.BodyParser(&returnData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	data := middlewares.XSSMiddleware(&returnData).(*CreateReturnRequestData)
+	if errors := helpers.StructValidation(data); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	for _, photo := range data.Photos {
+		if !services.IsUploadedImage(photo) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"status":     "unprocessable entity",
+				"statusCode": 422,
+				"message":    "Validation failed",
+				"errors":     []*helpers.ErrorResponse{{ErrorMessage: "photos must contain uploaded images"}},
+			})
+		}
+	}
+
+	returnRequest := models.ReturnRequest{
+		OrderID: order.ID,
+		Reason:  data.Reason,
+		Photos:  data.Photos,
+	}
+	for _, item := range data.Items {
+		returnRequest.Items = append(returnRequest.Items, models.ReturnItem{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+		})
+	}
+
+	if err := models.CreateReturnRequest(&returnRequest); errors.Is(err, models.ErrOrderStatus) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":     "conflict",
+			"statusCode": 409,
+			"message":    "Only delivered orders can be returned",
+		})
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    fmt.Sprintf("Return failed: %v", err),
+		})
+	} else if errors.Is(err, models.ErrReturnQuantity) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    fmt.Sprintf("Return failed: %v", err),
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to request return",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    "Return requested successfully",
+		"data":       returnResult(models.SelectReturnRequestById(int(returnRequest.ID))),
+	})
+}
+
+func GetReturnRequests(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	role := auth["role"].(string)
+	if role != "customer" && role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	status := c.Query("status")
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+
+	var returnRequests []*models.ReturnRequest
+	var totalData int64
+	if role == "customer" {
+		customer := models.SelectCustomerByUserId(int(id))
+		if customer.ID == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":     "not found",
+				"statusCode": 404,
+				"message":    "Customer not found",
+			})
+		}
+		totalData = models.CountReturnRequestsByCustomerId(int(customer.ID), status)
+		returnRequests = models.SelectReturnRequestsByCustomerId(int(customer.ID), status, limit, offset)
+	} else {
+		seller := models.SelectSellerByUserId(int(id))
+		if seller.ID == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":     "not found",
+				"statusCode": 404,
+				"message":    "Seller not found",
+			})
+		}
+		totalData = models.CountReturnRequestsBySellerId(int(seller.ID), status)
+		returnRequests = models.SelectReturnRequestsBySellerId(int(seller.ID), status, limit, offset)
+	}
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	if len(returnRequests) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Return is empty",
+		})
+	}
+
+	resultReturns := make([]map[string]interface{}, len(returnRequests))
+	for i, returnRequest := range returnRequests {
+		resultReturns[i] = returnResult(returnRequest)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultReturns,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+// findReturnRequest loads the return in the :id param if the user, with the
+// given role unless role is empty, may see it.
+func findReturnRequest(c *fiber.Ctx, role string) (*models.ReturnRequest, *fiber.Error) {
+	auth := middlewares.UserLocals(c)
+	if role == "" {
+		role = auth["role"].(string)
+	} else if auth["role"].(string) != role {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Incorrect role")
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid ID format")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid ID format")
+	}
+
+	returnRequest := models.SelectReturnRequestById(id)
+	if returnRequest.ID == 0 || !canViewOrder(role, int(userId), &returnRequest.Order) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Return not found")
+	}
+	return returnRequest, nil
+}
+
+func GetDetailReturnRequest(c *fiber.Ctx) error {
+	returnRequest, findErr := findReturnRequest(c, "")
+	if findErr != nil {
+		return errorResponse(c, findErr)
+	}
+
+	result := returnResult(returnRequest)
+	if shipment := models.SelectShipmentByReturnRequestId(int(returnRequest.ID)); shipment.ID != 0 {
+		result["shipment"] = shipment
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data":       result,
+	})
+}
+
+func AcceptReturnRequest(c *fiber.Ctx) error {
+	returnRequest, findErr := findReturnRequest(c, "seller")
+	if findErr != nil {
+		return errorResponse(c, findErr)
+	}
+
+	if err := models.ReviewReturnRequest(returnRequest.ID, true, "", time.Now()); errors.Is(err, models.ErrReturnProcessed) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Return already processed",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to accept return with ID %d", returnRequest.ID),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    fmt.Sprintf("Return with ID %d accepted successfully", returnRequest.ID),
+	})
+}
+
+func RejectReturnRequest(c *fiber.Ctx) error {
+	var rejectionData ReturnRejection
+
+	returnRequest, findErr := findReturnRequest(c, "seller")
+	if findErr != nil {
+		return errorResponse(c, findErr)
+	}
+
+	if err := c.BodyParser(&rejectionData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	rejection := middlewares.XSSMiddleware(&rejectionData).(*ReturnRejection)
+	if errors := helpers.StructValidation(rejection); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	if err := models.ReviewReturnRequest(returnRequest.ID, false, rejection.Note, time.Now()); errors.Is(err, models.ErrReturnProcessed) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Return already processed",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to reject return with ID %d", returnRequest.ID),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    fmt.Sprintf("Return with ID %d rejected successfully", returnRequest.ID),
+	})
+}
+
+func ShipReturn(c *fiber.Ctx) error {
+	var shipData ShipOrderRequest
+
+	returnRequest, findErr := findReturnRequest(c, "customer")
+	if findErr != nil {
+		return errorResponse(c, findErr)
+	}
+
+	if err := c.BodyParser(&shipData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	if errors := helpers.StructValidation(&shipData); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	shipment := models.Shipment{
+		Courier:        strings.TrimSpace(shipData.Courier),
+		TrackingNumber: strings.TrimSpace(shipData.TrackingNumber),
+	}
+	if err := models.ShipReturn(returnRequest.ID, &shipment, time.Now()); errors.Is(err, models.ErrReturnProcessed) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Only accepted returns can be shipped",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to ship return",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    "Return shipped successfully",
+		"data":       returnResult(models.SelectReturnRequestById(int(returnRequest.ID))),
+	})
+}
+
+// ReceiveReturn restocks the returned items and refunds the customer. If the
+// payment provider fails the return stays received, and calling this again
+// retries the refund.
+func ReceiveReturn(c *fiber.Ctx) error {
+	var receiveData ReceiveReturnRequest
+
+	returnRequest, findErr := findReturnRequest(c, "seller")
+	if findErr != nil {
+		return errorResponse(c, findErr)
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&receiveData); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":     "bad request",
+				"statusCode": 400,
+				"message":    "Invalid request body",
+			})
+		}
+	}
+
+	if errors := helpers.StructValidation(&receiveData); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	if receiveData.RefundAmount > returnRequest.Amount {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    fmt.Sprintf("Refund cannot exceed %s", returnRequest.Amount),
+		})
+	}
+
+	received, err := models.ReceiveReturn(returnRequest.ID, receiveData.RefundAmount, time.Now())
+	if errors.Is(err, models.ErrReturnProcessed) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Return already processed",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to receive return",
+		})
+	}
+
+	var reference string
+	payment := models.SelectPaymentById(int(returnRequest.Order.PaymentID))
+	if received.RefundAmount > 0 && payment.ProviderRef != "" {
+		reference, err = services.DefaultPaymentProvider.Refund(services.RefundRequest{
+			ChargeReference: payment.ProviderRef,
+			Amount:          received.RefundAmount,
+			Currency:        received.Currency,
+			Reference:       received.Reference(),
+		})
+		if err != nil {
+			log.Printf("Failed to refund return %d: %v", received.ID, err)
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+				"status":     "bad gateway",
+				"statusCode": 502,
+				"message":    "Return received but the refund failed, try again",
+			})
+		}
+	}
+
+	if err := models.CompleteRefund(received.ID, reference, time.Now()); err != nil {
+		log.Printf("Return %d was refunded as %q but could not be completed: %v", received.ID, reference, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to complete refund",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    "Return refunded successfully",
+		"data":       returnResult(models.SelectReturnRequestById(int(received.ID))),
+	})
+}
+
+// handleReturnStatus tells the seller about new and shipped returns and the
+// customer about the seller's decision and the refund.
+func handleReturnStatus(ctx context.Context, payload []byte) error {
+	var data models.ReturnStatusPayload
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return err
+	}
+
+	returnRequest := models.SelectReturnRequestById(int(data.ReturnID))
+	if returnRequest.ID == 0 {
+		return nil
+	}
+	order := models.SelectOrderById(int(returnRequest.OrderID))
+
+	switch data.Status {
+	case models.ReturnRequested:
+		message := fmt.Sprintf("%s asked to return items of order %s", order.Customer.Name, order.Number)
+		return services.DefaultNotifier.Notify(order.Seller.UserID, models.NotificationReturnStatus, "Return requested", message)
+	case models.ReturnAccepted:
+		message := fmt.Sprintf("%s accepted your return for order %s", order.Seller.Name, order.Number)
+		return services.DefaultNotifier.Notify(order.Customer.UserID, models.NotificationReturnStatus, "Return accepted", message)
+	case models.ReturnRejected:
+		message := fmt.Sprintf("%s rejected your return for order %s: %s", order.Seller.Name, order.Number, returnRequest.Note)
+		return services.DefaultNotifier.Notify(order.Customer.UserID, models.NotificationReturnStatus, "Return rejected", message)
+	case models.ReturnShipped:
+		message := fmt.Sprintf("%s shipped the return for order %s", order.Customer.Name, order.Number)
+		return services.DefaultNotifier.Notify(order.Seller.UserID, models.NotificationReturnStatus, "Return shipped", message)
+	case models.ReturnRefunded:
+		message := fmt.Sprintf("%s %s was refunded for order %s", returnRequest.RefundAmount, returnRequest.Currency, order.Number)
+		return services.DefaultNotifier.Notify(order.Customer.UserID, models.NotificationReturnStatus, "Refund issued", message)
+	}
+	return nil
+}
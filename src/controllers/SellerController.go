@@ -1,11 +1,14 @@
 package controllers
 
 import (
+	"fmt"
 	"gofiber-marketplace/src/helpers"
 	"gofiber-marketplace/src/middlewares"
 	"gofiber-marketplace/src/models"
 	"gofiber-marketplace/src/services"
+	"math"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -13,8 +16,11 @@ import (
 func GetSellers(c *fiber.Ctx) error {
 	keyword := c.Query("search")
 	sort := helpers.GetSortParams(c.Query("sorting"), c.Query("orderBy"))
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountSellers(keyword)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
 
-	sellers := models.SelectAllSellers(keyword, sort)
+	sellers := models.SelectAllSellers(keyword, sort, limit, offset)
 	if len(sellers) == 0 {
 		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
 			"status":     "no content",
@@ -25,50 +31,30 @@ func GetSellers(c *fiber.Ctx) error {
 
 	resultSellers := make([]map[string]interface{}, len(sellers))
 	for i, seller := range sellers {
-		products := make([]map[string]interface{}, len(seller.Products))
-		for j, product := range seller.Products {
-			var categoryName string
-			if product.CategoryID != 0 {
-				category := models.SelectCategoryById(int(product.CategoryID))
-				if category.ID != 0 {
-					categoryName = category.Name
-				}
-			}
-
-			products[j] = map[string]interface{}{
-				"id":            product.ID,
-				"created_at":    product.CreatedAt,
-				"updated_at":    product.UpdatedAt,
-				"name":          product.Name,
-				"price":         product.Price,
-				"size":          product.Size,
-				"color":         product.Color,
-				"photo":         product.Image,
-				"rating":        product.Rating,
-				"category_name": categoryName,
-			}
-		}
-
 		resultSellers[i] = map[string]interface{}{
-			"id":         seller.ID,
-			"created_at": seller.CreatedAt,
-			"updated_at": seller.UpdatedAt,
-			"name":       seller.Name,
-			"user_id":    seller.User.ID,
-			"email":      seller.User.Email,
-			"photo":      seller.Image,
-			"phone":      seller.Phone,
-			"desc":       seller.Description,
-			"role":       seller.User.Role,
-			"products":   products,
+			"id":            seller.ID,
+			"created_at":    seller.CreatedAt,
+			"updated_at":    seller.UpdatedAt,
+			"name":          seller.Name,
+			"user_id":       seller.User.ID,
+			"email":         seller.User.Email,
+			"photo":         seller.Image,
+			"phone":         seller.Phone,
+			"desc":          seller.Description,
+			"role":          seller.User.Role,
+			"product_count": seller.ProductCount,
 		}
 	}
 
 	// return c.JSON(categories)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"status":     "success",
-		"statusCode": 200,
-		"data":       resultSellers,
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultSellers,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
 	})
 }
 
@@ -99,17 +85,69 @@ func GetDetailSeller(c *fiber.Ctx) error {
 		})
 	}
 
-	products := make([]map[string]interface{}, len(seller.Products))
-	for i, product := range seller.Products {
-		var categoryName string
-		if product.CategoryID != 0 {
-			category := models.SelectCategoryById(int(product.CategoryID))
-			if category.ID != 0 {
-				categoryName = category.Name
-			}
-		}
+	resultSeller := map[string]interface{}{
+		"id":                 seller.ID,
+		"created_at":         seller.CreatedAt,
+		"updated_at":         seller.UpdatedAt,
+		"name":               seller.Name,
+		"user_id":            seller.User.ID,
+		"email":              seller.User.Email,
+		"photo":              seller.Image,
+		"phone":              seller.Phone,
+		"desc":               seller.Description,
+		"role":               seller.User.Role,
+		"handle":             seller.Handle,
+		"banner":             seller.Banner,
+		"location":           seller.Location,
+		"on_vacation":        helpers.IsOnVacation(seller, time.Now()),
+		"product_count":      seller.ProductCount,
+		"prices_include_tax": seller.PricesIncludeTax,
+		"currency":           seller.Currency,
+	}
+
+	// return c.JSON(product)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data":       resultSeller,
+	})
+}
+
+func GetSellerProducts(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
 
-		products[i] = map[string]interface{}{
+	if seller := models.SelectSellerById(id); seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	sort := helpers.GetSortParams(c.Query("sorting"), c.Query("orderBy"))
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountProductsBySellerId(id)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	products := models.SelectProductsBySellerId(id, sort, limit, offset)
+	if len(products) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Product of this seller is empty",
+		})
+	}
+
+	resultProducts := make([]map[string]interface{}, len(products))
+	for i, product := range products {
+		resultProducts[i] = map[string]interface{}{
 			"id":            product.ID,
 			"created_at":    product.CreatedAt,
 			"updated_at":    product.UpdatedAt,
@@ -119,29 +157,19 @@ func GetDetailSeller(c *fiber.Ctx) error {
 			"color":         product.Color,
 			"photo":         product.Image,
 			"rating":        product.Rating,
-			"category_name": categoryName,
+			"category_id":   product.CategoryID,
+			"category_name": product.Category.Name,
 		}
 	}
 
-	resultSeller := map[string]interface{}{
-		"id":         seller.ID,
-		"created_at": seller.CreatedAt,
-		"updated_at": seller.UpdatedAt,
-		"name":       seller.Name,
-		"user_id":    seller.User.ID,
-		"email":      seller.User.Email,
-		"photo":      seller.Image,
-		"phone":      seller.Phone,
-		"desc":       seller.Description,
-		"role":       seller.User.Role,
-		"products":   products,
-	}
-
-	// return c.JSON(product)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"status":     "success",
-		"statusCode": 200,
-		"data":       resultSeller,
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultProducts,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
 	})
 }
 
@@ -173,42 +201,22 @@ func GetSellerProfile(c *fiber.Ctx) error {
 		})
 	}
 
-	products := make([]map[string]interface{}, len(seller.Products))
-	for i, product := range seller.Products {
-		var categoryName string
-		if product.CategoryID != 0 {
-			category := models.SelectCategoryById(int(product.CategoryID))
-			if category.ID != 0 {
-				categoryName = category.Name
-			}
-		}
-
-		products[i] = map[string]interface{}{
-			"id":            product.ID,
-			"created_at":    product.CreatedAt,
-			"updated_at":    product.UpdatedAt,
-			"name":          product.Name,
-			"price":         product.Price,
-			"size":          product.Size,
-			"color":         product.Color,
-			"photo":         product.Image,
-			"rating":        product.Rating,
-			"category_name": categoryName,
-		}
-	}
-
 	resultSeller := map[string]interface{}{
-		"id":         seller.ID,
-		"created_at": seller.CreatedAt,
-		"updated_at": seller.UpdatedAt,
-		"name":       seller.Name,
-		"user_id":    seller.User.ID,
-		"email":      seller.User.Email,
-		"photo":      seller.Image,
-		"phone":      seller.Phone,
-		"desc":       seller.Description,
-		"role":       seller.User.Role,
-		"products":   products,
+		"id":            seller.ID,
+		"created_at":    seller.CreatedAt,
+		"updated_at":    seller.UpdatedAt,
+		"name":          seller.Name,
+		"user_id":       seller.User.ID,
+		"email":         seller.User.Email,
+		"photo":         seller.Image,
+		"phone":         seller.Phone,
+		"desc":          seller.Description,
+		"role":          seller.User.Role,
+		"handle":        seller.Handle,
+		"banner":        seller.Banner,
+		"location":      seller.Location,
+		"on_vacation":   helpers.IsOnVacation(seller, time.Now()),
+		"product_count": seller.ProductCount,
 	}
 
 	// return c.JSON(product)
@@ -224,6 +232,9 @@ type SellerProfile struct {
 	Email       string `json:"email" validate:"required,email"`
 	Phone       string `json:"phone" validate:"required,numeric,max=15"`
 	Description string `json:"description" validate:"required"`
+	Handle      string `json:"handle" validate:"omitempty,lowercase,alphanum,min=3,max=30"`
+	Location    string `json:"location" validate:"max=100"`
+	Currency    string `json:"currency" validate:"omitempty,len=3,uppercase"`
 }
 
 func UpdateSellerProfile(c *fiber.Ctx) error {
@@ -274,6 +285,22 @@ func UpdateSellerProfile(c *fiber.Ctx) error {
 		})
 	}
 
+	if user.Currency != "" && !helpers.SupportedCurrency(user.Currency) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    fmt.Sprintf("Currency %s is not supported", user.Currency),
+		})
+	}
+
+	if user.Currency != "" && user.Currency != seller.Currency && models.SellerHasListings(int(seller.ID)) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Currency cannot be changed once the seller has listings",
+		})
+	}
+
 	if seller.User.Email != user.Email {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":     "bad request",
@@ -282,6 +309,14 @@ func UpdateSellerProfile(c *fiber.Ctx) error {
 		})
 	}
 
+	if user.Handle != "" && user.Handle != seller.Handle && models.HandleTaken(user.Handle) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Handle already exists",
+		})
+	}
+
 	updatedUser := models.User{
 		Email: user.Email,
 	}
@@ -290,6 +325,9 @@ func UpdateSellerProfile(c *fiber.Ctx) error {
 		Name:        user.Name,
 		Phone:       user.Phone,
 		Description: user.Description,
+		Handle:      user.Handle,
+		Location:    user.Location,
+		Currency:    user.Currency,
 	}
 
 	if err := models.UpdateUser(int(id), &updatedUser); err != nil {
@@ -427,6 +465,242 @@ func UpdateSellerProfilePhoto(c *fiber.Ctx) error {
 
 }
 
+func UpdateSellerBanner(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Failed to upload file",
+		})
+	}
+
+	if err := helpers.ImageValidation(file); len(err) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     err,
+		})
+	}
+
+	uploadResult, err := services.UploadCloudinary(c, file)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to save file",
+		})
+	}
+
+	updatedSeller := models.Seller{
+		Banner: uploadResult.URL,
+	}
+
+	if err := models.UpdateSeller(int(seller.ID), &updatedSeller); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to update banner",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    "Banner updated successfully",
+	})
+
+}
+
+type SellerTaxSetting struct {
+	PricesIncludeTax bool `json:"prices_include_tax"`
+}
+
+type SellerVacation struct {
+	OnVacation bool   `json:"on_vacation"`
+	ReturnDate string `json:"return_date"`
+	Note       string `json:"note" validate:"max=255"`
+}
+
+func UpdateSellerVacation(c *fiber.Ctx) error {
+	var vacationData SellerVacation
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	if err := c.BodyParser(&vacationData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	vacation := middlewares.XSSMiddleware(&vacationData).(*SellerVacation)
+	if errors := helpers.StructValidation(vacation); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	var returnDate *time.Time
+	if vacation.OnVacation && vacation.ReturnDate != "" {
+		parsedDate, err := time.Parse("2006-01-02", vacation.ReturnDate)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":     "bad request",
+				"statusCode": 400,
+				"message":    "Date format invalid",
+			})
+		}
+
+		if !parsedDate.After(time.Now()) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":     "bad request",
+				"statusCode": 400,
+				"message":    "Return date must be in the future",
+			})
+		}
+		returnDate = &parsedDate
+	}
+
+	if !vacation.OnVacation {
+		vacation.Note = ""
+	}
+
+	if err := models.UpdateSellerVacation(int(seller.ID), vacation.OnVacation, returnDate, vacation.Note); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to update vacation mode",
+		})
+	}
+
+	message := "Vacation mode turned off"
+	if vacation.OnVacation {
+		message = "Vacation mode turned on"
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    message,
+	})
+}
+
+func UpdateSellerTaxMode(c *fiber.Ctx) error {
+	var taxData SellerTaxSetting
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	if err := c.BodyParser(&taxData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	if err := models.UpdateSellerTaxMode(int(seller.ID), taxData.PricesIncludeTax); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to update tax setting",
+		})
+	}
+
+	message := "Prices are now shown before tax"
+	if taxData.PricesIncludeTax {
+		message = "Prices are now shown including tax"
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    message,
+	})
+}
+
 func DeleteSeller(c *fiber.Ctx) error {
 	auth := middlewares.UserLocals(c)
 	if role := auth["role"].(string); role != "seller" {
@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"gofiber-marketplace/src/services"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ShippingQuoteRequest struct {
+	AddressID uint       `json:"address_id" validate:"required"`
+	Items     []CartItem `json:"items" validate:"required,min=1,dive"`
+}
+
+type sellerParcel struct {
+	seller      *models.Seller
+	weightGrams int
+}
+
+func QuoteShipping(c *fiber.Ctx) error {
+	var quoteData ShippingQuoteRequest
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "customer" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	if err := c.BodyParser(&quoteData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	if errors := helpers.StructValidation(&quoteData); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	address := models.SelectAddressbyId(int(quoteData.AddressID))
+	if address.ID == 0 || address.UserID != uint(id) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Address not found",
+		})
+	}
+
+	now := time.Now()
+	parcels := make(map[uint]*sellerParcel)
+	var sellerIds []uint
+	for _, item := range quoteData.Items {
+		product := models.SelectProductById(int(item.ProductID))
+		if product.ID == 0 || product.Status != models.ListingActive {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":     "not found",
+				"statusCode": 404,
+				"message":    fmt.Sprintf("Product with ID %d not found", item.ProductID),
+			})
+		}
+
+		if helpers.IsOnVacation(&product.Seller, now) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":     "bad request",
+				"statusCode": 400,
+				"message":    fmt.Sprintf("Seller of product with ID %d is on vacation", item.ProductID),
+			})
+		}
+
+		parcel, ok := parcels[product.SellerID]
+		if !ok {
+			parcel = &sellerParcel{seller: &product.Seller}
+			parcels[product.SellerID] = parcel
+			sellerIds = append(sellerIds, product.SellerID)
+		}
+		parcel.weightGrams += helpers.ChargeableWeight(product) * item.Quantity
+	}
+
+	resultQuotes := make([]map[string]interface{}, len(sellerIds))
+	for i, sellerId := range sellerIds {
+		parcel := parcels[sellerId]
+		request := services.ShippingRequest{
+			OriginCity:            parcel.seller.Location,
+			DestinationCity:       address.City,
+			DestinationPostalCode: address.PostalCode,
+			WeightGrams:           parcel.weightGrams,
+		}
+
+		options, err := services.QuoteShipping(request)
+		var providerErr *services.ShippingProviderError
+		if errors.As(err, &providerErr) {
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+				"status":     "bad gateway",
+				"statusCode": 502,
+				"message":    fmt.Sprintf("Shipping provider %s failed", providerErr.Provider),
+			})
+		}
+
+		resultQuotes[i] = map[string]interface{}{
+			"seller_id":   sellerId,
+			"seller_name": parcel.seller.Name,
+			"weight":      parcel.weightGrams,
+			"options":     options,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data":       resultQuotes,
+	})
+}
+
+type TrackingUpdate struct {
+	EventID        string    `json:"event_id" validate:"required,max=100"`
+	Courier        string    `json:"courier" validate:"required"`
+	TrackingNumber string    `json:"tracking_number" validate:"required"`
+	Status         string    `json:"status" validate:"required,oneof=in_transit out_for_delivery delivered failed"`
+	Description    string    `json:"description" validate:"max=255"`
+	Location       string    `json:"location" validate:"max=100"`
+	OccurredAt     time.Time `json:"occurred_at" validate:"required"`
+}
+
+// ReceiveTrackingUpdate is called by couriers when a parcel moves. The body is
+// signed like our outgoing webhooks, with TRACKING_WEBHOOK_SECRET.
+func ReceiveTrackingUpdate(c *fiber.Ctx) error {
+	var updateData TrackingUpdate
+
+	secret := helpers.TrackingWebhookSecret()
+	timestamp, err := strconv.ParseInt(c.Get("X-Tracking-Timestamp"), 10, 64)
+	if secret == "" || err != nil || !helpers.VerifyWebhookSignature(secret, timestamp, c.Body(), c.Get("X-Tracking-Signature")) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":     "unauthorized",
+			"statusCode": 401,
+			"message":    "Invalid signature",
+		})
+	}
+
+	if age := time.Since(time.Unix(timestamp, 0)); age > helpers.TrackingWebhookTolerance || age < -helpers.TrackingWebhookTolerance {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":     "unauthorized",
+			"statusCode": 401,
+			"message":    "Timestamp is outside the tolerance",
+		})
+	}
+
+	if err := json.Unmarshal(c.Body(), &updateData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	if errors := helpers.StructValidation(&updateData); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	shipment := models.SelectShipmentByTrackingNumber(updateData.Courier, updateData.TrackingNumber)
+	if shipment.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Shipment not found",
+		})
+	}
+
+	event := models.ShipmentEvent{
+		Status:      models.ShipmentStatus(updateData.Status),
+		Description: updateData.Description,
+		Location:    updateData.Location,
+		ExternalID:  updateData.EventID,
+		OccurredAt:  updateData.OccurredAt,
+	}
+	if err := models.RecordShipmentEvent(shipment.ID, &event); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to record tracking update",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    "Tracking update recorded",
+	})
+}
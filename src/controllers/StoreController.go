@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/models"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetStore(c *fiber.Ctx) error {
+	seller := models.SelectSellerByHandle(c.Params("handle"))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Store not found",
+		})
+	}
+
+	onVacation := helpers.IsOnVacation(seller, time.Now())
+	resultStore := map[string]interface{}{
+		"id":             seller.ID,
+		"handle":         seller.Handle,
+		"name":           seller.Name,
+		"photo":          seller.Image,
+		"banner":         seller.Banner,
+		"location":       seller.Location,
+		"desc":           seller.Description,
+		"joined_at":      seller.CreatedAt,
+		"product_count":  seller.ProductCount,
+		"average_rating": math.Round(seller.AverageRating*10) / 10,
+		"on_vacation":    onVacation,
+	}
+	if onVacation {
+		resultStore["vacation_until"] = seller.VacationUntil
+		resultStore["vacation_note"] = seller.VacationNote
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data":       resultStore,
+	})
+}
+
+func GetStoreProducts(c *fiber.Ctx) error {
+	seller := models.SelectSellerByHandle(c.Params("handle"))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Store not found",
+		})
+	}
+
+	currency := helpers.DisplayCurrency(c.Query("currency"), c.Get("Accept-Currency"))
+	if currency != "" && !helpers.SupportedCurrency(currency) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    fmt.Sprintf("Currency %s is not supported", currency),
+		})
+	}
+
+	sort := helpers.GetSortParams(c.Query("sorting"), c.Query("orderBy"))
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountProductsBySellerId(int(seller.ID))
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	products := models.SelectProductsBySellerId(int(seller.ID), sort, limit, offset)
+	if len(products) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Product of this store is empty",
+		})
+	}
+
+	productIds := make([]uint, len(products))
+	for i, product := range products {
+		productIds[i] = product.ID
+	}
+	now := time.Now()
+	onVacation := helpers.IsOnVacation(seller, now)
+	promotions := models.SelectActivePromotionsByProductIds(productIds, now)
+
+	resultProducts := make([]map[string]interface{}, len(products))
+	for i, product := range products {
+		resultProducts[i] = map[string]interface{}{
+			"id":            product.ID,
+			"name":          product.Name,
+			"photo":         product.Image,
+			"rating":        product.Rating,
+			"stock":         product.Stock,
+			"available":     product.Stock > 0 && !onVacation,
+			"category_id":   product.CategoryID,
+			"category_name": product.Category.Name,
+		}
+		for key, value := range helpers.DisplayPriceFields(product, helpers.PromotionFields(product, promotions[product.ID]), currency) {
+			resultProducts[i][key] = value
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultProducts,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
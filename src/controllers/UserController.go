@@ -71,13 +71,17 @@ func RegisterUser(c *fiber.Ctx) error {
 	}
 
 	if newUser.Role == "seller" {
-		newSeller := models.Seller{
-			UserID: userId,
-			Name:   user.Name,
-			Phone:  user.Phone,
-		}
-
-		if err := models.CreateSeller(&newSeller); err != nil {
+		err := helpers.SaveWithUniqueHandle(user.Name, func(handle string) error {
+			newSeller := models.Seller{
+				UserID:   userId,
+				Name:     user.Name,
+				Phone:    user.Phone,
+				Handle:   handle,
+				Currency: helpers.BaseCurrency(),
+			}
+			return models.CreateSeller(&newSeller)
+		})
+		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"status":     "server error",
 				"statusCode": 500,
@@ -187,6 +191,32 @@ func LoginUser(c *fiber.Ctx) error {
 	})
 }
 
+func CreateStreamTicket(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	payload := map[string]interface{}{
+		"id":    auth["id"],
+		"email": auth["email"],
+		"role":  auth["role"],
+	}
+
+	ticket, err := helpers.GenerateStreamTicket(os.Getenv("SECRETKEY"), payload)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Could not generate stream ticket",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 201,
+		"message":    "Stream ticket created",
+		"ticket":     ticket,
+		"expires_in": int(helpers.StreamTicketTTL.Seconds()),
+	})
+}
+
 func CreateRefreshToken(c *fiber.Ctx) error {
 	var refreshData struct {
 		RefreshToken string `json:"refresh_token"`
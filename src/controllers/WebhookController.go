@@ -0,0 +1,519 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"gofiber-marketplace/src/helpers"
+	"gofiber-marketplace/src/middlewares"
+	"gofiber-marketplace/src/models"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func queueProductWebhooks(before, after *models.Product) error {
+	data := map[string]interface{}{
+		"id":     after.ID,
+		"name":   after.Name,
+		"price":  after.Price,
+		"stock":  after.Stock,
+		"status": after.Status,
+	}
+
+	events := []string{models.WebhookProductUpdated}
+	if threshold := helpers.LowStockThreshold(); before.Stock > threshold && after.Stock <= threshold {
+		events = append(events, models.WebhookStockLow)
+	}
+	return helpers.QueueWebhook(after.SellerID, data, events...)
+}
+
+func webhookEndpointResult(endpoint *models.WebhookEndpoint) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         endpoint.ID,
+		"created_at": endpoint.CreatedAt,
+		"updated_at": endpoint.UpdatedAt,
+		"url":        endpoint.URL,
+		"events":     strings.Split(endpoint.Events, ","),
+		"active":     endpoint.Active,
+	}
+}
+
+func GetWebhookEndpoints(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	endpoints := models.SelectWebhookEndpointsBySellerId(int(seller.ID))
+	if len(endpoints) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Webhook endpoint is empty",
+		})
+	}
+
+	resultEndpoints := make([]map[string]interface{}, len(endpoints))
+	for i, endpoint := range endpoints {
+		resultEndpoints[i] = webhookEndpointResult(endpoint)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"data":       resultEndpoints,
+	})
+}
+
+type WebhookEndpointRequest struct {
+	URL    string   `json:"url" validate:"required,url"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=product.updated stock.low"`
+	Active *bool    `json:"active"`
+}
+
+func CreateWebhookEndpoint(c *fiber.Ctx) error {
+	var endpointData WebhookEndpointRequest
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	id, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(id))
+	if seller.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Seller not found",
+		})
+	}
+
+	if err := c.BodyParser(&endpointData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	if errors := helpers.StructValidation(&endpointData); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	if err := helpers.ValidateWebhookURL(endpointData.URL); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    fmt.Sprintf("Webhook URL is not allowed: %v", err),
+		})
+	}
+
+	secret, err := helpers.GenerateWebhookSecret()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to generate webhook secret",
+		})
+	}
+
+	newEndpoint := models.WebhookEndpoint{
+		SellerID: seller.ID,
+		URL:      endpointData.URL,
+		Secret:   secret,
+		Events:   strings.Join(endpointData.Events, ","),
+		Active:   endpointData.Active == nil || *endpointData.Active,
+	}
+
+	if err := models.CreateWebhookEndpoint(&newEndpoint); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to create webhook endpoint",
+		})
+	}
+
+	// The secret is only shown once; sellers need it to verify signatures.
+	resultEndpoint := webhookEndpointResult(&newEndpoint)
+	resultEndpoint["secret"] = newEndpoint.Secret
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":     "success",
+		"statusCode": 200,
+		"message":    "Webhook endpoint created successfully",
+		"data":       resultEndpoint,
+	})
+}
+
+func UpdateWebhookEndpoint(c *fiber.Ctx) error {
+	var endpointData WebhookEndpointRequest
+
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(userId))
+	endpoint := models.SelectWebhookEndpointById(id)
+	if endpoint.ID == 0 || seller.ID == 0 || endpoint.SellerID != seller.ID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Webhook endpoint not found",
+		})
+	}
+
+	if err := c.BodyParser(&endpointData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid request body",
+		})
+	}
+
+	if errors := helpers.StructValidation(&endpointData); len(errors) > 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":     "unprocessable entity",
+			"statusCode": 422,
+			"message":    "Validation failed",
+			"errors":     errors,
+		})
+	}
+
+	if err := helpers.ValidateWebhookURL(endpointData.URL); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    fmt.Sprintf("Webhook URL is not allowed: %v", err),
+		})
+	}
+
+	active := endpoint.Active
+	if endpointData.Active != nil {
+		active = *endpointData.Active
+	}
+
+	if err := models.UpdateWebhookEndpoint(id, endpointData.URL, strings.Join(endpointData.Events, ","), active); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to update webhook endpoint with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Webhook endpoint with ID %d updated successfully", id),
+		})
+	}
+}
+
+func DeleteWebhookEndpoint(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(userId))
+	endpoint := models.SelectWebhookEndpointById(id)
+	if endpoint.ID == 0 || seller.ID == 0 || endpoint.SellerID != seller.ID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Webhook endpoint not found",
+		})
+	}
+
+	if err := models.DeleteWebhookEndpoint(id); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to delete webhook endpoint with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Webhook endpoint with ID %d deleted successfully", id),
+		})
+	}
+}
+
+func TestWebhookEndpoint(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(userId))
+	endpoint := models.SelectWebhookEndpointById(id)
+	if endpoint.ID == 0 || seller.ID == 0 || endpoint.SellerID != seller.ID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Webhook endpoint not found",
+		})
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"event":       models.WebhookPing,
+		"occurred_at": time.Now(),
+		"data":        map[string]interface{}{"seller_id": seller.ID},
+	})
+
+	if err := helpers.QueueWebhookDelivery(endpoint.ID, models.WebhookPing, string(payload)); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    "Failed to queue test delivery",
+		})
+	} else {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    "Test delivery queued",
+		})
+	}
+}
+
+func GetWebhookDeliveries(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(userId))
+	endpoint := models.SelectWebhookEndpointById(id)
+	if endpoint.ID == 0 || seller.ID == 0 || endpoint.SellerID != seller.ID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Webhook endpoint not found",
+		})
+	}
+
+	page, limit, offset := helpers.GetPaginationParams(c.Query("limit"), c.Query("page"))
+	totalData := models.CountWebhookDeliveriesByEndpointId(id)
+	totalPage := math.Ceil(float64(totalData) / float64(limit))
+
+	deliveries := models.SelectWebhookDeliveriesByEndpointId(id, limit, offset)
+	if len(deliveries) == 0 {
+		return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+			"status":     "no content",
+			"statusCode": 202,
+			"message":    "Webhook delivery is empty",
+		})
+	}
+
+	resultDeliveries := make([]map[string]interface{}, len(deliveries))
+	for i, delivery := range deliveries {
+		resultDeliveries[i] = map[string]interface{}{
+			"id":              delivery.ID,
+			"created_at":      delivery.CreatedAt,
+			"event":           delivery.Event,
+			"payload":         json.RawMessage(delivery.Payload),
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"response_code":   delivery.ResponseCode,
+			"error":           delivery.Error,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"delivered_at":    delivery.DeliveredAt,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"statusCode":  200,
+		"data":        resultDeliveries,
+		"currentPage": page,
+		"limit":       limit,
+		"totalData":   totalData,
+		"totalPage":   totalPage,
+	})
+}
+
+// RedeliverWebhook queues a fresh copy of a past delivery, leaving the
+// original attempt log untouched.
+func RedeliverWebhook(c *fiber.Ctx) error {
+	auth := middlewares.UserLocals(c)
+	if role := auth["role"].(string); role != "seller" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":     "forbidden",
+			"statusCode": 403,
+			"message":    "Incorrect role",
+		})
+	}
+
+	userId, ok := auth["id"].(float64)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":     "bad request",
+			"statusCode": 400,
+			"message":    "Invalid ID format",
+		})
+	}
+
+	seller := models.SelectSellerByUserId(int(userId))
+	delivery := models.SelectWebhookDeliveryById(id)
+	if delivery.ID == 0 || seller.ID == 0 || delivery.Endpoint.SellerID != seller.ID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":     "not found",
+			"statusCode": 404,
+			"message":    "Webhook delivery not found",
+		})
+	}
+
+	if err := helpers.QueueWebhookDelivery(delivery.EndpointID, delivery.Event, delivery.Payload); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":     "server error",
+			"statusCode": 500,
+			"message":    fmt.Sprintf("Failed to redeliver webhook delivery with ID %d", id),
+		})
+	} else {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"status":     "success",
+			"statusCode": 200,
+			"message":    fmt.Sprintf("Webhook delivery with ID %d queued for redelivery", id),
+		})
+	}
+}
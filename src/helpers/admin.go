@@ -0,0 +1,42 @@
+package helpers
+
+import (
+	"gofiber-marketplace/src/models"
+	"log"
+	"os"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BootstrapAdmin creates the admin account named by ADMIN_EMAIL and
+// ADMIN_PASSWORD if it does not exist yet. Registration only creates sellers
+// and customers, so this is how the first admin gets in.
+func BootstrapAdmin() {
+	email, password := os.Getenv("ADMIN_EMAIL"), os.Getenv("ADMIN_PASSWORD")
+	if email == "" || password == "" {
+		return
+	}
+
+	if existUser := models.SelectUserbyEmail(email); existUser.ID != 0 {
+		if existUser.Role != "admin" {
+			log.Printf("ADMIN_EMAIL %s belongs to a %s account, not creating an admin", email, existUser.Role)
+		}
+		return
+	}
+
+	admin := models.User{Email: email, Password: password, Role: "admin"}
+	if errors := PasswordValidation(password, StructValidation(&admin)); len(errors) > 0 {
+		log.Fatalf("ADMIN_EMAIL or ADMIN_PASSWORD is invalid: %s", errors[0].ErrorMessage)
+	}
+
+	hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Fatalf("Failed to hash admin password: %v", err)
+	}
+
+	admin.Password = string(hashPassword)
+	if _, err := models.CreateUser(&admin); err != nil {
+		log.Fatalf("Failed to create admin: %v", err)
+	}
+	log.Printf("Created admin %s", email)
+}
@@ -0,0 +1,112 @@
+package helpers
+
+import (
+	"errors"
+	"gofiber-marketplace/src/models"
+	"math"
+	"strconv"
+	"time"
+)
+
+const analyticsDateFormat = "2006-01-02"
+
+// maxAnalyticsDays keeps a daily series to a size a chart can show.
+const maxAnalyticsDays = 366
+
+type AnalyticsRange struct {
+	From        time.Time
+	To          time.Time
+	Granularity string
+}
+
+type SalesTotals struct {
+	Revenue           models.Money `json:"revenue"`
+	Orders            int64        `json:"orders"`
+	Units             int64        `json:"units"`
+	AverageOrderValue models.Money `json:"average_order_value"`
+	Views             int64        `json:"views"`
+	ConversionRate    float64      `json:"conversion_rate"`
+}
+
+// ParseAnalyticsRange reads from and to as inclusive UTC dates. Without them
+// the range is the last 30 days up to today. The returned To is exclusive.
+func ParseAnalyticsRange(fromParam, toParam, granularity string, now time.Time) (*AnalyticsRange, error) {
+	result := AnalyticsRange{Granularity: granularity}
+	if result.Granularity == "" {
+		result.Granularity = "day"
+	}
+	if result.Granularity != "day" && result.Granularity != "week" && result.Granularity != "month" {
+		return nil, errors.New("granularity must contain day, week or month")
+	}
+
+	to := now.UTC().Truncate(24 * time.Hour)
+	if toParam != "" {
+		parsed, err := time.Parse(analyticsDateFormat, toParam)
+		if err != nil {
+			return nil, errors.New("to must contain a date in YYYY-MM-DD format")
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -29)
+	if fromParam != "" {
+		parsed, err := time.Parse(analyticsDateFormat, fromParam)
+		if err != nil {
+			return nil, errors.New("from must contain a date in YYYY-MM-DD format")
+		}
+		from = parsed
+	}
+
+	result.From, result.To = from, to.AddDate(0, 0, 1)
+	if !result.From.Before(result.To) {
+		return nil, errors.New("from must contain a date before to")
+	}
+	if result.To.Sub(result.From) > maxAnalyticsDays*24*time.Hour {
+		return nil, errors.New("range must contain at most " + strconv.Itoa(maxAnalyticsDays) + " days")
+	}
+	return &result, nil
+}
+
+// AverageOrderValue is the revenue per order, 0 when there were no orders.
+func AverageOrderValue(revenue models.Money, orders int64) models.Money {
+	if orders == 0 {
+		return 0
+	}
+	return models.Money(math.Round(float64(revenue) / float64(orders)))
+}
+
+// ConversionRate is the percentage of product views that turned into an
+// order.
+func ConversionRate(orders, views int64) float64 {
+	if views == 0 {
+		return 0
+	}
+	return math.Round(float64(orders)/float64(views)*10000) / 100
+}
+
+func SumSalesPeriods(periods []*models.SalesPeriod) SalesTotals {
+	var totals SalesTotals
+	for _, period := range periods {
+		totals.Revenue += period.Revenue
+		totals.Orders += period.Orders
+		totals.Units += period.Units
+		totals.Views += period.Views
+	}
+	totals.AverageOrderValue = AverageOrderValue(totals.Revenue, totals.Orders)
+	totals.ConversionRate = ConversionRate(totals.Orders, totals.Views)
+	return totals
+}
+
+var SalesCSVHeader = []string{"period", "revenue", "orders", "units", "average_order_value", "views", "conversion_rate"}
+
+func SalesCSVRecord(period *models.SalesPeriod) []string {
+	return []string{
+		period.Period.Format(analyticsDateFormat),
+		period.Revenue.String(),
+		strconv.FormatInt(period.Orders, 10),
+		strconv.FormatInt(period.Units, 10),
+		AverageOrderValue(period.Revenue, period.Orders).String(),
+		strconv.FormatInt(period.Views, 10),
+		strconv.FormatFloat(ConversionRate(period.Orders, period.Views), 'f', -1, 64),
+	}
+}
@@ -0,0 +1,161 @@
+package helpers
+
+import (
+	"gofiber-marketplace/src/models"
+	"gofiber-marketplace/src/services"
+)
+
+// CartLine is one product in a cart, priced in the cart's currency.
+type CartLine struct {
+	Product   *models.Product
+	Promotion *models.Promotion
+	Quantity  int
+	UnitPrice models.Money
+	Amount    models.Money
+	// Discount is the line's share of the cart discounts. It comes off before
+	// tax, so tax is only charged on what the customer actually pays.
+	Discount  models.Money
+	TaxRate   float64
+	Inclusive bool
+	Net       models.Money
+	Tax       models.Money
+	Gross     models.Money
+}
+
+// CartGroup is the part of a cart sold by one seller, which is shipped as one
+// parcel and becomes one order.
+type CartGroup struct {
+	Seller       *models.Seller
+	Lines        []*CartLine
+	WeightGrams  int
+	Shipping     *services.ShippingOption
+	ShippingCost models.Money
+	Subtotal     models.Money
+	Discount     models.Money
+	Tax          models.Money
+	Total        models.Money
+}
+
+type Cart struct {
+	Currency string
+	Groups   []*CartGroup
+	Subtotal models.Money
+	Discount models.Money
+	Tax      models.Money
+	Shipping models.Money
+	Total    models.Money
+	Taxes    TaxBreakdown
+}
+
+// Add puts quantity units of the product in the cart at its effective price.
+// Adding a product that is already in the cart raises its quantity.
+func (c *Cart) Add(product *models.Product, promotion *models.Promotion, quantity int) *CartLine {
+	if c.Currency == "" {
+		c.Currency = product.Currency
+	}
+
+	var group *CartGroup
+	for _, g := range c.Groups {
+		if g.Seller.ID == product.SellerID {
+			group = g
+			break
+		}
+	}
+	if group == nil {
+		group = &CartGroup{Seller: &product.Seller}
+		c.Groups = append(c.Groups, group)
+	}
+	group.WeightGrams += ChargeableWeight(product) * quantity
+
+	for _, line := range group.Lines {
+		if line.Product.ID == product.ID {
+			line.Quantity += quantity
+			line.Amount = line.UnitPrice.Times(line.Quantity)
+			return line
+		}
+	}
+
+	line := &CartLine{
+		Product:   product,
+		Quantity:  quantity,
+		UnitPrice: EffectivePrice(product, promotion),
+		TaxRate:   TaxRate(&product.Category),
+		Inclusive: product.Seller.PricesIncludeTax,
+	}
+	if line.UnitPrice != product.Price {
+		line.Promotion = promotion
+	}
+	line.Amount = line.UnitPrice.Times(quantity)
+	group.Lines = append(group.Lines, line)
+	return line
+}
+
+func (c *Cart) Lines() []*CartLine {
+	var lines []*CartLine
+	for _, group := range c.Groups {
+		lines = append(lines, group.Lines...)
+	}
+	return lines
+}
+
+// Payable is what is left of the lines after earlier discounts.
+func Payable(lines []*CartLine) models.Money {
+	var total models.Money
+	for _, line := range lines {
+		total += line.Amount - line.Discount
+	}
+	return total
+}
+
+// ApplyDiscount spreads amount over the lines in proportion to what is left
+// of each after earlier discounts. Shares are rounded on the running total,
+// so they add up to exactly amount.
+func (c *Cart) ApplyDiscount(lines []*CartLine, amount models.Money) {
+	payable := Payable(lines)
+	if amount > payable {
+		amount = payable
+	}
+	if amount <= 0 {
+		return
+	}
+
+	var running, allocated models.Money
+	for _, line := range lines {
+		running += line.Amount - line.Discount
+		share := models.Money(float64(amount)*float64(running)/float64(payable)+0.5) - allocated
+		line.Discount += share
+		allocated += share
+	}
+}
+
+// Calculate works out tax on the discounted lines and the totals of every
+// group and of the cart. Call it again after changing discounts or shipping.
+func (c *Cart) Calculate() {
+	c.Subtotal, c.Discount, c.Tax, c.Shipping, c.Total = 0, 0, 0, 0, 0
+	c.Taxes = TaxBreakdown{}
+
+	for _, group := range c.Groups {
+		group.Subtotal, group.Discount, group.Tax = 0, 0, 0
+		for _, line := range group.Lines {
+			c.Taxes.Add(line.Product.ID, line.Quantity, line.Amount-line.Discount, line.TaxRate, line.Inclusive)
+			taxLine := c.Taxes.Lines[len(c.Taxes.Lines)-1]
+			line.Net, line.Tax, line.Gross = taxLine.Net, taxLine.Tax, taxLine.Gross
+
+			group.Subtotal += line.Amount
+			group.Discount += line.Discount
+			group.Tax += line.Tax
+		}
+		group.Total = group.Subtotal - group.Discount + group.ShippingCost
+		for _, line := range group.Lines {
+			if !line.Inclusive {
+				group.Total += line.Tax
+			}
+		}
+
+		c.Subtotal += group.Subtotal
+		c.Discount += group.Discount
+		c.Tax += group.Tax
+		c.Shipping += group.ShippingCost
+		c.Total += group.Total
+	}
+}
@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"gofiber-marketplace/src/models"
+)
+
+func CalculateCouponDiscount(coupon *models.Coupon, subtotal models.Money) models.Money {
+	var discount models.Money
+	switch coupon.Type {
+	case models.Percentage:
+		discount = subtotal.Percent(coupon.Percent)
+	case models.Fixed:
+		discount = coupon.Amount
+	}
+
+	if coupon.MaxDiscount > 0 && discount > coupon.MaxDiscount {
+		discount = coupon.MaxDiscount
+	}
+
+	if discount > subtotal {
+		discount = subtotal
+	}
+
+	return discount
+}
+
+// CouponLines returns the cart lines the coupon's category and seller scope
+// allow it to discount.
+func CouponLines(coupon *models.Coupon, cart *Cart) []*CartLine {
+	var lines []*CartLine
+	for _, line := range cart.Lines() {
+		if coupon.CategoryID != nil && *coupon.CategoryID != line.Product.CategoryID {
+			continue
+		}
+		if coupon.SellerID != nil && *coupon.SellerID != line.Product.SellerID {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,52 @@
+package helpers
+
+import (
+	"gofiber-marketplace/src/models"
+	"gofiber-marketplace/src/services"
+	"os"
+	"strings"
+)
+
+func BaseCurrency() string {
+	if currency := strings.ToUpper(os.Getenv("BASE_CURRENCY")); currency != "" {
+		return currency
+	}
+	return "IDR"
+}
+
+// DisplayCurrency picks the currency prices should be shown in, preferring
+// ?currency= over the Accept-Currency header. Empty means no conversion.
+func DisplayCurrency(query, header string) string {
+	if query != "" {
+		return strings.ToUpper(strings.TrimSpace(query))
+	}
+	return strings.ToUpper(strings.TrimSpace(header))
+}
+
+func SupportedCurrency(currency string) bool {
+	_, err := services.DefaultExchangeRates.Rate(BaseCurrency(), currency)
+	return err == nil
+}
+
+// DisplayPriceFields adds the promotion price fields converted to the display
+// currency. Products are still charged in their own currency, so the
+// original fields are left untouched.
+func DisplayPriceFields(product *models.Product, fields map[string]interface{}, currency string) map[string]interface{} {
+	fields["currency"] = product.Currency
+	if currency == "" || currency == product.Currency {
+		return fields
+	}
+
+	rate, err := services.DefaultExchangeRates.Rate(product.Currency, currency)
+	if err != nil {
+		return fields
+	}
+
+	fields["display_currency"] = currency
+	fields["display_price"] = fields["price"].(models.Money).Convert(rate)
+	fields["display_original_price"] = fields["original_price"].(models.Money).Convert(rate)
+	if salePrice, ok := fields["sale_price"].(models.Money); ok {
+		fields["display_sale_price"] = salePrice.Convert(rate)
+	}
+	return fields
+}
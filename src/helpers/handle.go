@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"fmt"
+	"gofiber-marketplace/src/models"
+	"regexp"
+	"strings"
+)
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]`)
+
+// maxHandleAttempts bounds how often a generated handle is retried when a
+// concurrent registration takes it first.
+const maxHandleAttempts = 5
+
+func GenerateHandle(name string) string {
+	return nonAlphanumeric.ReplaceAllString(strings.ToLower(name), "")
+}
+
+// UniqueHandle derives a handle from the name that no seller, including a
+// deleted one, holds yet. Short names are padded and long ones truncated so
+// the result also passes the handle validation sellers are held to.
+func UniqueHandle(name string) string {
+	base := GenerateHandle(name)
+	if len(base) < 3 {
+		base = "seller" + base
+	}
+	if len(base) > 24 {
+		base = base[:24]
+	}
+
+	handle := base
+	for suffix := 2; models.HandleTaken(handle); suffix++ {
+		handle = fmt.Sprintf("%s%d", base, suffix)
+	}
+	return handle
+}
+
+// SaveWithUniqueHandle calls save with a fresh handle for the name, retrying
+// with the next free handle when another seller claimed it in the meantime.
+func SaveWithUniqueHandle(name string, save func(handle string) error) error {
+	var err error
+	for attempt := 0; attempt < maxHandleAttempts; attempt++ {
+		handle := UniqueHandle(name)
+		if err = save(handle); err == nil || !models.HandleTaken(handle) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+package helpers
+
+import (
+	"fmt"
+	"gofiber-marketplace/src/models"
+	"io"
+	"strconv"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// invoiceColumns are the widths in millimetres of the line item table, which
+// spans the 190mm between the A4 margins.
+var invoiceColumns = []float64{70, 15, 25, 20, 20, 15, 25}
+
+func invoiceAmount(amount models.Money, currency string) string {
+	return currency + " " + strconv.FormatFloat(amount.Float64(), 'f', 2, 64)
+}
+
+// WriteInvoicePDF renders the invoice of a paid order as an A4 PDF. The order
+// must be loaded with its items and customer.
+func WriteInvoicePDF(w io.Writer, invoice *models.Invoice, order *models.Order) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Invoice "+invoice.Number, true)
+	pdf.SetMargins(10, 15, 10)
+	pdf.AddPage()
+	// The core fonts are not Unicode, so names and addresses are mapped to
+	// the font's code page.
+	text := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(95, 10, "INVOICE", "", 0, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(95, 5, invoice.Number, "", 2, "R", false, 0, "")
+	pdf.CellFormat(95, 5, "Issued "+invoice.IssuedAt.Format("2 January 2006"), "", 1, "R", false, 0, "")
+	pdf.CellFormat(190, 5, "Order "+order.Number, "", 1, "R", false, 0, "")
+	pdf.Ln(6)
+
+	top := pdf.GetY()
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(95, 6, "Sold by", "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.MultiCell(90, 5, text(fmt.Sprintf("%s\n%s\n%s\n%s", invoice.SellerName, invoice.SellerAddress, invoice.SellerPhone, invoice.SellerEmail)), "", "L", false)
+	bottom := pdf.GetY()
+
+	pdf.SetXY(105, top)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(95, 6, "Bill to", "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.MultiCell(95, 5, text(fmt.Sprintf("%s\n%s", order.Customer.Name, order.Customer.Phone)), "", "L", false)
+	pdf.SetX(105)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(95, 6, "Ship to", "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.MultiCell(95, 5, text(fmt.Sprintf("%s\n%s\n%s\n%s %s\n%s", order.RecipientName, order.ShippingAddress, order.ShippingDetail, order.ShippingCity, order.ShippingPostalCode, order.RecipientPhone)), "", "L", false)
+	if pdf.GetY() < bottom {
+		pdf.SetY(bottom)
+	}
+	pdf.Ln(6)
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(235, 235, 235)
+	for i, header := range []string{"Item", "Qty", "Unit price", "Discount", "Net", "Tax %", "Total"} {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(invoiceColumns[i], 7, header, "B", 0, align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	for _, item := range order.Items {
+		cells := []string{
+			text(item.Name),
+			strconv.Itoa(item.Quantity),
+			strconv.FormatFloat(item.UnitPrice.Float64(), 'f', 2, 64),
+			strconv.FormatFloat(item.Discount.Float64(), 'f', 2, 64),
+			strconv.FormatFloat(item.Net.Float64(), 'f', 2, 64),
+			strconv.FormatFloat(item.TaxRate, 'f', -1, 64) + "%",
+			strconv.FormatFloat(item.Gross.Float64(), 'f', 2, 64),
+		}
+		for i, cell := range cells {
+			align := "R"
+			if i == 0 {
+				align = "L"
+				// Long product names are cut rather than wrapped so every
+				// item stays on one row.
+				for pdf.GetStringWidth(cell) > invoiceColumns[0]-2 && len(cell) > 3 {
+					cell = cell[:len(cell)-4] + "..."
+				}
+			}
+			pdf.CellFormat(invoiceColumns[i], 6, cell, "B", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+
+	// Sellers whose prices include tax still show it, but it does not add to
+	// the total.
+	taxLabel := "Tax"
+	if order.Total != order.Subtotal-order.Discount+order.Tax+order.ShippingCost {
+		taxLabel = "Tax (included)"
+	}
+	totals := []struct {
+		label  string
+		amount models.Money
+	}{
+		{"Subtotal", order.Subtotal},
+		{"Discount", -order.Discount},
+		{taxLabel, order.Tax},
+		{"Shipping", order.ShippingCost},
+		{"Total", order.Total},
+	}
+	for i, total := range totals {
+		if i == len(totals)-1 {
+			pdf.SetFont("Helvetica", "B", 10)
+		}
+		pdf.CellFormat(150, 6, total.label, "", 0, "R", false, 0, "")
+		pdf.CellFormat(40, 6, invoiceAmount(total.amount, order.Currency), "", 1, "R", false, 0, "")
+	}
+
+	if order.PaidAt != nil {
+		pdf.Ln(6)
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(190, 5, "Paid on "+order.PaidAt.Format("2 January 2006"), "", 1, "L", false, 0, "")
+	}
+
+	return pdf.Output(w)
+}
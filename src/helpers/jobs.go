@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"context"
+	"gofiber-marketplace/src/models"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// JobRetention is how long completed jobs are kept before they are deleted.
+func JobRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("JOB_RETENTION_DAYS"))
+	if err != nil || days < 1 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func CleanupFinishedJobs(ctx context.Context) error {
+	deleted, err := models.DeleteFinishedJobs(time.Now().Add(-JobRetention()))
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		log.Printf("Deleted %d finished jobs", deleted)
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const StreamTicketTTL = 30 * time.Second
+
 func GenerateToken(secretKey string, payload map[string]interface{}) (string, error) {
 	token := jwt.New(jwt.SigningMethodHS256)
 	claims := token.Claims.(jwt.MapClaims)
@@ -41,3 +43,19 @@ func GenerateRefreshToken(secretKey string, payload map[string]interface{}) (str
 
 	return refreshTokenString, nil
 }
+
+// GenerateStreamTicket issues a short-lived token that can only open the
+// notification and chat streams, for clients that must pass it in the URL.
+func GenerateStreamTicket(secretKey string, payload map[string]interface{}) (string, error) {
+	token := jwt.New(jwt.SigningMethodHS256)
+	claims := token.Claims.(jwt.MapClaims)
+
+	for key, value := range payload {
+		claims[key] = value
+	}
+
+	claims["scope"] = "stream"
+	claims["exp"] = time.Now().Add(StreamTicketTTL).Unix()
+
+	return token.SignedString([]byte(secretKey))
+}
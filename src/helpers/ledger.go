@@ -0,0 +1,28 @@
+package helpers
+
+import (
+	"gofiber-marketplace/src/models"
+	"os"
+	"strconv"
+	"time"
+)
+
+func LedgerHoldPeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("LEDGER_HOLD_DAYS"))
+	if err != nil || days < 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func CommissionRate(category *models.Category) float64 {
+	if category.CommissionRate != nil {
+		return *category.CommissionRate
+	}
+
+	rate, err := strconv.ParseFloat(os.Getenv("PLATFORM_COMMISSION_RATE"), 64)
+	if err != nil || rate < 0 {
+		rate = 0
+	}
+	return rate
+}
@@ -0,0 +1,119 @@
+package helpers
+
+import (
+	"context"
+	"gofiber-marketplace/src/models"
+	"gofiber-marketplace/src/services"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// LoyaltyEarnRate is the number of points earned per unit of the base
+// currency spent.
+func LoyaltyEarnRate() float64 {
+	rate, err := strconv.ParseFloat(os.Getenv("LOYALTY_EARN_RATE"), 64)
+	if err != nil || rate < 0 {
+		rate = 0.001
+	}
+	return rate
+}
+
+// LoyaltyPointValue is the discount one point is worth at checkout, in the
+// base currency.
+func LoyaltyPointValue() models.Money {
+	value, err := models.ParseMoney(os.Getenv("LOYALTY_POINT_VALUE"))
+	if err != nil || value <= 0 {
+		value = models.NewMoney(1)
+	}
+	return value
+}
+
+// LoyaltyMaxRedeemPercent caps the share of a cart total that points can pay.
+func LoyaltyMaxRedeemPercent() float64 {
+	percent, err := strconv.ParseFloat(os.Getenv("LOYALTY_MAX_REDEEM_PERCENT"), 64)
+	if err != nil || percent < 0 || percent > 100 {
+		percent = 50
+	}
+	return percent
+}
+
+// LoyaltyReviewPoints is the flat number of points a product review earns.
+func LoyaltyReviewPoints() int {
+	points, err := strconv.Atoi(os.Getenv("LOYALTY_REVIEW_POINTS"))
+	if err != nil || points < 0 {
+		points = 10
+	}
+	return points
+}
+
+func LoyaltyExpiry() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("LOYALTY_EXPIRY_DAYS"))
+	if err != nil || days < 1 {
+		days = 365
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// PointValue is what one point is worth in currency.
+func PointValue(currency string) (models.Money, error) {
+	rate, err := services.DefaultExchangeRates.Rate(BaseCurrency(), currency)
+	if err != nil {
+		return 0, err
+	}
+	return LoyaltyPointValue().Convert(rate), nil
+}
+
+// PointsForPurchase returns the points earned for spending amount in
+// currency, which is converted to the base currency first so every currency
+// earns at the same rate.
+func PointsForPurchase(amount models.Money, currency string) (int, error) {
+	rate, err := services.DefaultExchangeRates.Rate(currency, BaseCurrency())
+	if err != nil {
+		return 0, err
+	}
+	return int(math.Floor(amount.Convert(rate).Float64() * LoyaltyEarnRate())), nil
+}
+
+// EarnOrderPoints rewards the customer for a completed order. Points are
+// earned on what the customer paid less what was refunded on returns.
+func EarnOrderPoints(order *models.Order, now time.Time) error {
+	points, err := PointsForPurchase(order.Total-models.SelectRefundedAmount(int(order.ID)), order.Currency)
+	if err != nil {
+		return err
+	}
+	return models.EarnPoints(order.CustomerID, points, order.Number, now.Add(LoyaltyExpiry()))
+}
+
+// PointsDiscount returns how many of the requested points can be used on a
+// total in currency and the discount they give, never spending more points
+// than needed.
+func PointsDiscount(points int, total models.Money, currency string) (int, models.Money, error) {
+	value, err := PointValue(currency)
+	if err != nil {
+		return 0, 0, err
+	}
+	if value <= 0 {
+		return 0, 0, nil
+	}
+
+	limit := total.Percent(LoyaltyMaxRedeemPercent())
+	usable := min(points, int(limit/value))
+	return usable, value.Times(usable), nil
+}
+
+func ExpireLoyaltyPoints(ctx context.Context) error {
+	for ctx.Err() == nil {
+		expired, err := models.ExpirePoints(time.Now())
+		if err != nil {
+			return err
+		}
+		if expired == 0 {
+			return nil
+		}
+		log.Printf("Expired loyalty points on %d entries", expired)
+	}
+	return ctx.Err()
+}
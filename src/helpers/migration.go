@@ -6,7 +6,26 @@ import (
 	"log"
 )
 
+// migrateProductPrice converts products.price from the decimal it used to be
+// into models.Money, which stores hundredths in a bigint. A column that is
+// already bigint or does not exist yet is left to AutoMigrate.
+func migrateProductPrice() error {
+	var dataType string
+	if err := configs.DB.Raw("SELECT data_type FROM information_schema.columns WHERE table_schema = CURRENT_SCHEMA() AND table_name = 'products' AND column_name = 'price'").Scan(&dataType).Error; err != nil {
+		return err
+	}
+	if dataType == "" || dataType == "bigint" {
+		return nil
+	}
+
+	return configs.DB.Exec("ALTER TABLE products ALTER COLUMN price TYPE bigint USING ROUND(price * 100)::bigint").Error
+}
+
 func Migration() {
+	if err := migrateProductPrice(); err != nil {
+		log.Fatalf("Failed to convert product prices: %v", err)
+	}
+
 	// configs.DB.AutoMigrate(&models.Product{})
 	err := configs.DB.AutoMigrate(
 		&models.User{},
@@ -15,10 +34,51 @@ func Migration() {
 		&models.Product{},
 		&models.Category{},
 		&models.Address{},
+		&models.Wishlist{},
+		&models.ProductAlert{},
+		&models.ProductPriceHistory{},
+		&models.Coupon{},
+		&models.CouponRedemption{},
+		&models.Promotion{},
+		&models.PromotionClaim{},
+		&models.LedgerEntry{},
+		&models.Payout{},
+		&models.ProductImport{},
+		&models.ProductQuestion{},
+		&models.ProductAnswer{},
+		&models.ProductQAVote{},
+		&models.Conversation{},
+		&models.ChatMessage{},
+		&models.Notification{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.Job{},
+		&models.PointEntry{},
+		&models.Payment{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.ProductView{},
+		&models.Shipment{},
+		&models.ShipmentEvent{},
+		&models.ReturnRequest{},
+		&models.ReturnItem{},
+		&models.Invoice{},
+		&models.ProductReview{},
 	)
 
 	if err != nil {
 		// Handle the error, e.g., log it or panic
 		log.Fatalf("Failed to auto migrate: %v", err)
 	}
+
+	// Backfill handles with the same scheme as registration so the two can
+	// never produce colliding handles.
+	for _, seller := range models.SelectSellersWithoutHandle() {
+		err := SaveWithUniqueHandle(seller.Name, func(handle string) error {
+			return models.UpdateSellerHandle(int(seller.ID), handle)
+		})
+		if err != nil {
+			log.Fatalf("Failed to backfill seller handles: %v", err)
+		}
+	}
 }
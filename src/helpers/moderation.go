@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"fmt"
+	"gofiber-marketplace/src/models"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var defaultBannedKeywords = []string{"counterfeit", "replica", "narcotic", "firearm", "ammunition"}
+
+func BannedKeywords() []string {
+	keywords := os.Getenv("BANNED_KEYWORDS")
+	if keywords == "" {
+		return defaultBannedKeywords
+	}
+
+	var result []string
+	for _, keyword := range strings.Split(keywords, ",") {
+		if keyword = strings.TrimSpace(strings.ToLower(keyword)); keyword != "" {
+			result = append(result, keyword)
+		}
+	}
+	return result
+}
+
+type bannedKeyword struct {
+	keyword string
+	pattern *regexp.Regexp
+}
+
+var (
+	bannedPatterns     []bannedKeyword
+	bannedPatternsOnce sync.Once
+)
+
+// compiledBannedKeywords compiles the keyword list on first use. The list
+// comes from the environment, so it cannot change while the process runs.
+func compiledBannedKeywords() []bannedKeyword {
+	bannedPatternsOnce.Do(func() {
+		for _, keyword := range BannedKeywords() {
+			bannedPatterns = append(bannedPatterns, bannedKeyword{
+				keyword: keyword,
+				pattern: keywordPattern(keyword),
+			})
+		}
+	})
+	return bannedPatterns
+}
+
+var wordChar = regexp.MustCompile(`^\w$`)
+
+// keywordPattern matches keyword as a whole word. \b only sits between a word
+// and a non-word char, so it is added only on the sides where the keyword
+// starts or ends with a word char; "c++" or "$$$" would never match otherwise.
+func keywordPattern(keyword string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(keyword)
+	if wordChar.MatchString(keyword[:1]) {
+		pattern = `\b` + pattern
+	}
+	if wordChar.MatchString(keyword[len(keyword)-1:]) {
+		pattern += `\b`
+	}
+	return regexp.MustCompile(pattern)
+}
+
+func FindBannedKeywords(texts ...string) []string {
+	content := strings.ToLower(strings.Join(texts, " "))
+
+	var found []string
+	for _, banned := range compiledBannedKeywords() {
+		if banned.pattern.MatchString(content) {
+			found = append(found, banned.keyword)
+		}
+	}
+	return found
+}
+
+// ModerateProduct runs the automated pre-check on a submitted listing. Clean
+// listings wait for an admin in the review queue; listings with banned
+// keywords are rejected straight away with the matched words as the reason.
+func ModerateProduct(product *models.Product) {
+	if found := FindBannedKeywords(product.Name, product.Description); len(found) > 0 {
+		product.Status = models.ListingRejected
+		product.ModerationNote = fmt.Sprintf("Contains banned keywords: %s", strings.Join(found, ", "))
+		return
+	}
+
+	product.Status = models.ListingPendingReview
+	product.ModerationNote = ""
+}
@@ -0,0 +1,95 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"gofiber-marketplace/src/models"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// PaymentTimeout is how long checkout holds stock for an order that has not
+// been paid.
+func PaymentTimeout() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("PAYMENT_TIMEOUT_MINUTES"))
+	if err != nil || minutes < 1 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// Orders turns every seller group of the cart into an order shipped to the
+// address, copying the prices the cart was calculated with.
+func (c *Cart) Orders(customerId uint, address *models.Address) []*models.Order {
+	orders := make([]*models.Order, len(c.Groups))
+	for i, group := range c.Groups {
+		order := &models.Order{
+			CustomerID:         customerId,
+			SellerID:           group.Seller.ID,
+			RecipientName:      address.Name,
+			RecipientPhone:     address.Phone,
+			ShippingAddress:    address.MainAddress,
+			ShippingDetail:     address.DetailAddress,
+			ShippingCity:       address.City,
+			ShippingPostalCode: address.PostalCode,
+			Currency:           c.Currency,
+			Subtotal:           group.Subtotal,
+			Discount:           group.Discount,
+			Tax:                group.Tax,
+			ShippingCost:       group.ShippingCost,
+			Total:              group.Total,
+		}
+		if group.Shipping != nil {
+			order.ShippingProvider = group.Shipping.Provider
+			order.ShippingCourier = group.Shipping.Courier
+			order.ShippingService = group.Shipping.Service
+		}
+
+		for _, line := range group.Lines {
+			var promotionId *uint
+			if line.Promotion != nil {
+				promotionId = &line.Promotion.ID
+			}
+
+			order.Items = append(order.Items, models.OrderItem{
+				PromotionID:    promotionId,
+				ProductID:      line.Product.ID,
+				Name:           line.Product.Name,
+				Image:          line.Product.Image,
+				Quantity:       line.Quantity,
+				UnitPrice:      line.UnitPrice,
+				Amount:         line.Amount,
+				Discount:       line.Discount,
+				TaxRate:        line.TaxRate,
+				CommissionRate: CommissionRate(&line.Product.Category),
+				Net:            line.Net,
+				Tax:            line.Tax,
+				Gross:          line.Gross,
+			})
+		}
+		orders[i] = order
+	}
+	return orders
+}
+
+// ExpireUnpaidOrders cancels orders whose payment did not complete in time
+// and puts their stock back on sale.
+func ExpireUnpaidOrders(ctx context.Context) error {
+	for ctx.Err() == nil {
+		payments := models.SelectExpiredPayments(time.Now().Add(-PaymentTimeout()), 100)
+		if len(payments) == 0 {
+			return nil
+		}
+
+		for _, payment := range payments {
+			err := models.FailPayment(payment.ID, "payment timed out", time.Now())
+			if err != nil && !errors.Is(err, models.ErrPaymentProcessed) {
+				return err
+			}
+		}
+		log.Printf("Cancelled %d unpaid payments", len(payments))
+	}
+	return ctx.Err()
+}
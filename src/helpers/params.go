@@ -1,6 +1,8 @@
 package helpers
 
 import (
+	"gofiber-marketplace/src/models"
+	"reflect"
 	"strconv"
 )
 
@@ -31,3 +33,13 @@ func GetPaginationParams(oldLimit, oldPage string) (int, int, int) {
 
 	return page, limit, offset
 }
+
+// ParseMoneyParam lets form, query and header parsers read decimal amounts
+// into models.Money instead of treating them as hundredths.
+func ParseMoneyParam(value string) reflect.Value {
+	amount, err := models.ParseMoney(value)
+	if err != nil {
+		return reflect.Value{}
+	}
+	return reflect.ValueOf(amount)
+}
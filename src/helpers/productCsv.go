@@ -0,0 +1,148 @@
+package helpers
+
+import (
+	"gofiber-marketplace/src/models"
+	"strconv"
+	"strings"
+)
+
+var ProductCSVHeader = []string{"id", "name", "price", "stock", "image", "size", "color", "description", "condition", "category", "currency", "weight", "length", "width", "height"}
+
+// productCSVRequired is the part of the header an import must have. The later
+// columns are optional so files exported before they existed still import.
+var productCSVRequired = ProductCSVHeader[1:10]
+
+// formulaPrefixes are the leading characters spreadsheets treat as the start
+// of a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// escapeCSVCell prefixes a quote to cells a spreadsheet would evaluate, so an
+// exported product name cannot run a formula on the seller's machine. Cells
+// already starting with a quote get one too, so they survive the round trip.
+func escapeCSVCell(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes+"'", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeCSVCell reverses escapeCSVCell so exported files import unchanged.
+func unescapeCSVCell(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(formulaPrefixes+"'", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+func ProductCSVRecord(product *models.Product) []string {
+	return []string{
+		strconv.Itoa(int(product.ID)),
+		escapeCSVCell(product.Name),
+		product.Price.String(),
+		strconv.Itoa(product.Stock),
+		escapeCSVCell(product.Image),
+		strconv.Itoa(int(product.Size)),
+		escapeCSVCell(product.Color),
+		escapeCSVCell(product.Description),
+		escapeCSVCell(string(product.Condition)),
+		escapeCSVCell(product.Category.Slug),
+		product.Currency,
+		strconv.Itoa(product.Weight),
+		strconv.Itoa(product.Length),
+		strconv.Itoa(product.Width),
+		strconv.Itoa(product.Height),
+	}
+}
+
+// ProductCSVColumns maps each known header name to its column index and
+// reports the required columns that are missing from the header.
+func ProductCSVColumns(header []string) (map[string]int, []string) {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	var missing []string
+	for _, name := range productCSVRequired {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return columns, missing
+}
+
+// ParseProductCSVRecord reads one data row into a product. It returns the
+// product ID for rows that update an existing product (0 for new rows) and
+// the category slug, which the caller resolves against the database.
+func ParseProductCSVRecord(columns map[string]int, record []string) (*models.Product, int, string, []string) {
+	var errors []string
+	value := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return unescapeCSVCell(strings.TrimSpace(record[i]))
+		}
+		return ""
+	}
+
+	var id int
+	if raw := value("id"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			errors = append(errors, "id must contain numeric")
+		}
+		id = parsed
+	}
+
+	price, err := models.ParseMoney(value("price"))
+	if err != nil {
+		errors = append(errors, "price must contain numeric")
+	}
+
+	stock, err := strconv.Atoi(value("stock"))
+	if err != nil {
+		errors = append(errors, "stock must contain numeric")
+	}
+
+	size, err := strconv.Atoi(value("size"))
+	if err != nil || size < 0 {
+		errors = append(errors, "size must contain numeric")
+	}
+
+	dimensions := make(map[string]int, 4)
+	for _, name := range []string{"weight", "length", "width", "height"} {
+		if raw := value(name); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed < 0 {
+				errors = append(errors, name+" must contain numeric")
+			}
+			dimensions[name] = parsed
+		}
+	}
+
+	condition := models.ProductCondition(value("condition"))
+	if condition == "" {
+		condition = models.New
+	}
+
+	product := models.Product{
+		Name:        value("name"),
+		Price:       price,
+		Stock:       stock,
+		Image:       value("image"),
+		Size:        uint(size),
+		Color:       value("color"),
+		Description: value("description"),
+		Condition:   condition,
+		Currency:    strings.ToUpper(value("currency")),
+		Weight:      dimensions["weight"],
+		Length:      dimensions["length"],
+		Width:       dimensions["width"],
+		Height:      dimensions["height"],
+	}
+
+	category := value("category")
+	if category == "" {
+		errors = append(errors, "category must contain required")
+	}
+
+	return &product, id, category, errors
+}
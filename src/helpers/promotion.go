@@ -0,0 +1,28 @@
+package helpers
+
+import (
+	"gofiber-marketplace/src/models"
+)
+
+func EffectivePrice(product *models.Product, promotion *models.Promotion) models.Money {
+	if promotion != nil && promotion.ID != 0 && promotion.SalePrice < product.Price {
+		return promotion.SalePrice
+	}
+	return product.Price
+}
+
+func PromotionFields(product *models.Product, promotion *models.Promotion) map[string]interface{} {
+	result := map[string]interface{}{
+		"price":          EffectivePrice(product, promotion),
+		"original_price": product.Price,
+		"sale_price":     nil,
+		"sale_ends_at":   nil,
+	}
+
+	if promotion != nil && promotion.ID != 0 && promotion.SalePrice < product.Price {
+		result["sale_price"] = promotion.SalePrice
+		result["sale_ends_at"] = promotion.EndsAt
+	}
+
+	return result
+}
@@ -0,0 +1,94 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"gofiber-marketplace/src/models"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// TrackingWebhookTolerance is how far the timestamp of a tracking push may be
+// from now before it is treated as a replay.
+const TrackingWebhookTolerance = 5 * time.Minute
+
+// TrackingWebhookSecret is shared with the couriers that push tracking
+// updates. Pushes are rejected while it is not set.
+func TrackingWebhookSecret() string {
+	return os.Getenv("TRACKING_WEBHOOK_SECRET")
+}
+
+// OrderAutoCompletePeriod is how long a customer has to report a problem with
+// a delivered order before it completes on its own.
+func OrderAutoCompletePeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("ORDER_AUTO_COMPLETE_DAYS"))
+	if err != nil || days < 1 {
+		days = 3
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// CompleteDeliveredOrders completes orders that were delivered longer ago
+// than OrderAutoCompletePeriod.
+func CompleteDeliveredOrders(ctx context.Context) error {
+	for ctx.Err() == nil {
+		orders := models.SelectOrdersToComplete(time.Now().Add(-OrderAutoCompletePeriod()), 100)
+		if len(orders) == 0 {
+			return nil
+		}
+
+		for _, order := range orders {
+			err := models.CompleteOrder(order.ID, time.Now())
+			if err != nil && !errors.Is(err, models.ErrOrderStatus) && !errors.Is(err, models.ErrReturnOpen) {
+				return err
+			}
+		}
+		log.Printf("Completed %d delivered orders", len(orders))
+	}
+	return ctx.Err()
+}
+
+type TimelineEvent struct {
+	Status      string    `json:"status"`
+	Description string    `json:"description"`
+	Location    string    `json:"location,omitempty"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// OrderTimeline merges the order's own status changes with the tracking
+// events of its shipments, oldest first.
+func OrderTimeline(order *models.Order, shipments []*models.Shipment) []TimelineEvent {
+	events := []TimelineEvent{{
+		Status:      string(models.OrderPendingPayment),
+		Description: "Order placed",
+		OccurredAt:  order.CreatedAt,
+	}}
+	if order.PaidAt != nil {
+		events = append(events, TimelineEvent{Status: string(models.OrderPaid), Description: "Payment received", OccurredAt: *order.PaidAt})
+	}
+	if order.CancelledAt != nil {
+		events = append(events, TimelineEvent{Status: string(models.OrderCancelled), Description: "Order cancelled", OccurredAt: *order.CancelledAt})
+	}
+	if order.CompletedAt != nil {
+		events = append(events, TimelineEvent{Status: string(models.OrderCompleted), Description: "Order completed", OccurredAt: *order.CompletedAt})
+	}
+
+	for _, shipment := range shipments {
+		for _, event := range shipment.Events {
+			events = append(events, TimelineEvent{
+				Status:      string(event.Status),
+				Description: event.Description,
+				Location:    event.Location,
+				OccurredAt:  event.OccurredAt,
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OccurredAt.Before(events[j].OccurredAt)
+	})
+	return events
+}
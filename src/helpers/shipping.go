@@ -0,0 +1,30 @@
+package helpers
+
+import (
+	"gofiber-marketplace/src/models"
+	"os"
+	"strconv"
+)
+
+func DefaultProductWeight() int {
+	weight, err := strconv.Atoi(os.Getenv("DEFAULT_PRODUCT_WEIGHT"))
+	if err != nil || weight <= 0 {
+		weight = 1000
+	}
+	return weight
+}
+
+// ChargeableWeight returns the weight couriers bill for one unit in grams:
+// the larger of the actual weight and the volumetric weight (cm³ / 6000 kg).
+// Products without a weight fall back to DefaultProductWeight.
+func ChargeableWeight(product *models.Product) int {
+	weight := product.Weight
+	if weight == 0 {
+		weight = DefaultProductWeight()
+	}
+
+	if volumetric := product.Length * product.Width * product.Height / 6; volumetric > weight {
+		return volumetric
+	}
+	return weight
+}
@@ -0,0 +1,89 @@
+package helpers
+
+import (
+	"gofiber-marketplace/src/models"
+	"math"
+	"os"
+	"strconv"
+)
+
+type TaxLine struct {
+	ProductID uint         `json:"product_id"`
+	Quantity  int          `json:"quantity"`
+	Rate      float64      `json:"rate"`
+	Inclusive bool         `json:"inclusive"`
+	Net       models.Money `json:"net"`
+	Tax       models.Money `json:"tax"`
+	Gross     models.Money `json:"gross"`
+}
+
+type TaxRateTotal struct {
+	Rate float64      `json:"rate"`
+	Net  models.Money `json:"net"`
+	Tax  models.Money `json:"tax"`
+}
+
+// TaxBreakdown is kept self-contained so it can be stored as-is once totals
+// need to be snapshotted.
+type TaxBreakdown struct {
+	Lines []TaxLine      `json:"lines"`
+	Rates []TaxRateTotal `json:"rates"`
+	Net   models.Money   `json:"net"`
+	Tax   models.Money   `json:"tax"`
+	Gross models.Money   `json:"gross"`
+}
+
+// TaxRate returns the VAT percentage for products of the category. A rate of
+// 0 on the category marks it as exempt; no rate falls back to PPN_RATE.
+func TaxRate(category *models.Category) float64 {
+	if category.TaxRate != nil {
+		return *category.TaxRate
+	}
+
+	rate, err := strconv.ParseFloat(os.Getenv("PPN_RATE"), 64)
+	if err != nil || rate < 0 {
+		rate = 11
+	}
+	return rate
+}
+
+// SplitTax splits a line amount into net and tax. Inclusive amounts already
+// contain the tax, exclusive amounts get it added on top.
+func SplitTax(amount models.Money, rate float64, inclusive bool) (net, tax models.Money) {
+	if inclusive {
+		tax = models.Money(math.Round(float64(amount) * rate / (100 + rate)))
+		return amount - tax, tax
+	}
+
+	return amount, amount.Percent(rate)
+}
+
+func (b *TaxBreakdown) Add(productId uint, quantity int, amount models.Money, rate float64, inclusive bool) {
+	net, tax := SplitTax(amount, rate, inclusive)
+	b.Lines = append(b.Lines, TaxLine{
+		ProductID: productId,
+		Quantity:  quantity,
+		Rate:      rate,
+		Inclusive: inclusive,
+		Net:       net,
+		Tax:       tax,
+		Gross:     net + tax,
+	})
+
+	found := false
+	for i := range b.Rates {
+		if b.Rates[i].Rate == rate {
+			b.Rates[i].Net += net
+			b.Rates[i].Tax += tax
+			found = true
+			break
+		}
+	}
+	if !found {
+		b.Rates = append(b.Rates, TaxRateTotal{Rate: rate, Net: net, Tax: tax})
+	}
+
+	b.Net += net
+	b.Tax += tax
+	b.Gross += net + tax
+}
@@ -0,0 +1,27 @@
+package helpers
+
+import (
+	"context"
+	"gofiber-marketplace/src/models"
+	"log"
+	"time"
+)
+
+func IsOnVacation(seller *models.Seller, now time.Time) bool {
+	if !seller.OnVacation {
+		return false
+	}
+	return seller.VacationUntil == nil || now.Before(*seller.VacationUntil)
+}
+
+func EndExpiredVacations(ctx context.Context) error {
+	reopened, err := models.EndExpiredVacations(time.Now())
+	if err != nil {
+		return err
+	}
+
+	if reopened > 0 {
+		log.Printf("Reopened %d stores after vacation", reopened)
+	}
+	return nil
+}
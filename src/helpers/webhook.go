@@ -0,0 +1,272 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gofiber-marketplace/src/models"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+var errWebhookAddress = errors.New("address is loopback, private, link-local or unspecified")
+
+// webhookClient refuses to connect to internal addresses. The check runs on
+// the address actually dialled, after DNS resolution, so a host that resolves
+// differently at send time than at registration is still caught. Redirects
+// are not followed: a 3xx counts as a failed attempt.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, conn syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || (blockedWebhookIP(ip) && !WebhookAllowPrivate()) {
+					return errWebhookAddress
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(request *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// webhookDeliveryLease is how long a claimed delivery is hidden from other
+// workers. It must outlast one attempt, including the client timeout.
+const webhookDeliveryLease = time.Minute
+
+func blockedWebhookIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// WebhookAllowPrivate lets webhooks target plain http and internal addresses,
+// such as cmd/webhookreceiver on localhost. It is meant for local development
+// only and is off unless WEBHOOK_ALLOW_PRIVATE is "true".
+func WebhookAllowPrivate() bool {
+	return os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true"
+}
+
+// ValidateWebhookURL rejects endpoints that are not https or whose host
+// resolves to an internal address, unless WebhookAllowPrivate is set.
+func ValidateWebhookURL(rawURL string) error {
+	endpoint, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if endpoint.Hostname() == "" {
+		return errors.New("must be an absolute URL")
+	}
+	if WebhookAllowPrivate() {
+		if endpoint.Scheme != "https" && endpoint.Scheme != "http" {
+			return errors.New("must be an http or https URL")
+		}
+		return nil
+	}
+	if endpoint.Scheme != "https" {
+		return errors.New("must be an https URL")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, endpoint.Hostname())
+	if err != nil {
+		return fmt.Errorf("cannot resolve %s", endpoint.Hostname())
+	}
+	for _, address := range addresses {
+		if blockedWebhookIP(address.IP) {
+			return errWebhookAddress
+		}
+	}
+	return nil
+}
+
+func GenerateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// SignWebhookPayload signs "<timestamp>.<payload>" so receivers can reject
+// replayed deliveries by checking the timestamp header.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyWebhookSignature(secret string, timestamp int64, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhookPayload(secret, timestamp, payload)), []byte(signature))
+}
+
+func WebhookMaxAttempts() int {
+	attempts, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS"))
+	if err != nil || attempts < 1 {
+		attempts = 6
+	}
+	return attempts
+}
+
+// WebhookBackoff returns the wait before the next attempt: 30s, 1m, 2m, 4m...
+func WebhookBackoff(attempts int) time.Duration {
+	return time.Duration(math.Pow(2, float64(attempts-1))) * 30 * time.Second
+}
+
+func LowStockThreshold() int {
+	threshold, err := strconv.Atoi(os.Getenv("LOW_STOCK_THRESHOLD"))
+	if err != nil || threshold < 0 {
+		threshold = 5
+	}
+	return threshold
+}
+
+// QueueWebhook records a pending delivery for every active endpoint of the
+// seller subscribed to each event. The deliveries are created together, so a
+// retried caller does not queue the ones that already went through twice.
+// The scheduler sends them.
+func QueueWebhook(sellerId uint, data interface{}, events ...string) error {
+	now := time.Now()
+
+	var deliveries []*models.WebhookDelivery
+	for _, event := range events {
+		endpoints := models.SelectWebhookEndpointsForEvent(sellerId, event)
+		if len(endpoints) == 0 {
+			continue
+		}
+
+		payload, err := json.Marshal(map[string]interface{}{
+			"event":       event,
+			"occurred_at": now,
+			"data":        data,
+		})
+		if err != nil {
+			return fmt.Errorf("encode %s webhook: %w", event, err)
+		}
+
+		for _, endpoint := range endpoints {
+			deliveries = append(deliveries, &models.WebhookDelivery{
+				EndpointID:    endpoint.ID,
+				Event:         event,
+				Payload:       string(payload),
+				Status:        models.WebhookPending,
+				NextAttemptAt: &now,
+			})
+		}
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return models.CreateWebhookDeliveries(deliveries)
+}
+
+func QueueWebhookDelivery(endpointId uint, event, payload string) error {
+	now := time.Now()
+	return models.CreateWebhookDelivery(&models.WebhookDelivery{
+		EndpointID:    endpointId,
+		Event:         event,
+		Payload:       payload,
+		Status:        models.WebhookPending,
+		NextAttemptAt: &now,
+	})
+}
+
+// DeliverWebhook makes one attempt and records its outcome. Non-2xx answers
+// and network errors are retried with exponential backoff until
+// WebhookMaxAttempts is reached.
+func DeliverWebhook(delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseCode = 0
+	delivery.Error = ""
+
+	if delivery.Endpoint.ID == 0 || !delivery.Endpoint.Active {
+		delivery.Status = models.WebhookFailed
+		delivery.Error = "endpoint removed or inactive"
+		delivery.NextAttemptAt = nil
+		return models.SaveWebhookDelivery(delivery)
+	}
+
+	request, err := http.NewRequest(http.MethodPost, delivery.Endpoint.URL, bytes.NewBufferString(delivery.Payload))
+	if err == nil {
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Webhook-Event", delivery.Event)
+		request.Header.Set("X-Webhook-Delivery", strconv.Itoa(int(delivery.ID)))
+		request.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(now.Unix(), 10))
+		request.Header.Set("X-Webhook-Signature", SignWebhookPayload(delivery.Endpoint.Secret, now.Unix(), []byte(delivery.Payload)))
+
+		var response *http.Response
+		if response, err = webhookClient.Do(request); err == nil {
+			// Only the status is kept; the body is drained so the
+			// connection can be reused.
+			io.Copy(io.Discard, io.LimitReader(response.Body, 4096))
+			response.Body.Close()
+
+			delivery.ResponseCode = response.StatusCode
+			if response.StatusCode < 200 || response.StatusCode > 299 {
+				err = fmt.Errorf("unexpected status %d", response.StatusCode)
+			}
+		}
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookSucceeded
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= WebhookMaxAttempts():
+		delivery.Status = models.WebhookFailed
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(WebhookBackoff(delivery.Attempts))
+		delivery.Status = models.WebhookPending
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = &next
+	}
+
+	return models.SaveWebhookDelivery(delivery)
+}
+
+func DeliverDueWebhooks(ctx context.Context) error {
+	deliveries, err := models.ClaimDueWebhookDeliveries(time.Now(), 50, webhookDeliveryLease)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := DeliverWebhook(delivery); err != nil {
+			log.Printf("Failed to save webhook delivery %d: %v", delivery.ID, err)
+		}
+	}
+	return nil
+}
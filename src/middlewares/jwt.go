@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -29,12 +30,7 @@ func JWTMiddleware() fiber.Handler {
 			})
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(secretKey), nil
-		})
+		token, err := parseToken(tokenString, secretKey)
 
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -44,7 +40,7 @@ func JWTMiddleware() fiber.Handler {
 			})
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid && claims["scope"] == nil {
 			c.Locals("user", claims)
 		} else {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -58,6 +54,85 @@ func JWTMiddleware() fiber.Handler {
 	}
 }
 
+func OptionalJWTMiddleware() fiber.Handler {
+	secretKey := os.Getenv("SECRETKEY")
+	return func(c *fiber.Ctx) error {
+		tokenString := ExtractToken(c)
+		if tokenString == "" {
+			return c.Next()
+		}
+
+		token, err := parseToken(tokenString, secretKey)
+		if err == nil {
+			if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid && claims["scope"] == nil {
+				c.Locals("user", claims)
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// StreamTicketMiddleware runs the same token check as JWTMiddleware but also
+// accepts a stream ticket in the ticket query parameter. Browser WebSocket and
+// EventSource clients cannot set an Authorization header, and a short-lived
+// ticket that only opens streams keeps access tokens out of request logs.
+func StreamTicketMiddleware() fiber.Handler {
+	secretKey := os.Getenv("SECRETKEY")
+	jwtMiddleware := JWTMiddleware()
+	return func(c *fiber.Ctx) error {
+		ticket := c.Query("ticket")
+		if ExtractToken(c) != "" || ticket == "" {
+			return jwtMiddleware(c)
+		}
+
+		token, err := parseToken(ticket, secretKey)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status":     "unauthorized",
+				"statusCode": 401,
+				"message":    "Ticket unauthorized",
+			})
+		}
+
+		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid && claims["scope"] == "stream" {
+			c.Locals("user", claims)
+		} else {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status":     "unauthorized",
+				"statusCode": 401,
+				"message":    "Ticket claims unauthorized",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+func WebSocketJWTMiddleware() fiber.Handler {
+	jwtMiddleware := StreamTicketMiddleware()
+	return func(c *fiber.Ctx) error {
+		if !websocket.IsWebSocketUpgrade(c) {
+			return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{
+				"status":     "upgrade required",
+				"statusCode": 426,
+				"message":    "WebSocket upgrade required",
+			})
+		}
+
+		return jwtMiddleware(c)
+	}
+}
+
+func parseToken(tokenString, secretKey string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(secretKey), nil
+	})
+}
+
 // func Authorize(requiredRole string) fiber.Handler {
 // 	return func(c *fiber.Ctx) error {
 // 		user := c.Locals("user").(jwt.MapClaims)
@@ -81,6 +156,12 @@ func UserLocals(c *fiber.Ctx) jwt.MapClaims {
 	return user
 }
 
+func OptionalUserLocals(c *fiber.Ctx) (jwt.MapClaims, bool) {
+	user, ok := c.Locals("user").(jwt.MapClaims)
+
+	return user, ok
+}
+
 // func JWTValidation(requiredRole string, c *fiber.Ctx) error {
 // 	user := c.Locals("user").(jwt.MapClaims)
 // 	role := user["role"].(string)
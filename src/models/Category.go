@@ -8,28 +8,40 @@ import (
 
 type Category struct {
 	gorm.Model
-	Name     string    `json:"name" validate:"required,max=50"`
-	Image    string    `json:"image" validate:"required"`
-	Slug     string    `json:"slug" validate:"required,lowercase"`
-	Products []Product `json:"products"`
+	Name           string    `json:"name" validate:"required,max=50"`
+	Image          string    `json:"image" validate:"required"`
+	Slug           string    `json:"slug" validate:"required,lowercase"`
+	CommissionRate *float64  `json:"commission_rate" validate:"omitempty,gte=0,lte=100"`
+	TaxRate        *float64  `json:"tax_rate" validate:"omitempty,gte=0,lte=100"`
+	Products       []Product `json:"products"`
+	ProductCount   int64     `json:"product_count" gorm:"->;-:migration" validate:"-"`
 }
 
-func SelectAllCategories(keyword, sort string) []*Category {
+const categoryProductCount = "(SELECT COUNT(*) FROM products WHERE products.category_id = categories.id AND products.deleted_at IS NULL AND products.status = 'active') AS product_count"
+
+func SelectAllCategories(keyword, sort string, limit, offset int) []*Category {
 	var categories []*Category
 	keyword = "%" + keyword + "%"
-	configs.DB.Preload("Products").Order(sort).Where("name ILIKE ?", keyword).Find(&categories)
+	configs.DB.Select("categories.*, "+categoryProductCount).Order(sort).Limit(limit).Offset(offset).Where("name ILIKE ?", keyword).Find(&categories)
 	return categories
 }
 
+func CountCategories(keyword string) int64 {
+	var result int64
+	keyword = "%" + keyword + "%"
+	configs.DB.Table("categories").Where("deleted_at IS NULL AND name ILIKE ?", keyword).Count(&result)
+	return result
+}
+
 func SelectCategoryById(id int) *Category {
 	var category Category
-	configs.DB.Preload("Products").First(&category, "id = ?", id)
+	configs.DB.Select("categories.*, "+categoryProductCount).First(&category, "id = ?", id)
 	return &category
 }
 
 func SelectCategoryBySlug(slug string) *Category {
 	var category Category
-	configs.DB.Preload("Products").First(&category, "slug = ?", slug)
+	configs.DB.First(&category, "slug = ?", slug)
 	return &category
 }
 
@@ -38,9 +50,20 @@ func CreateCategory(category *Category) error {
 	return result.Error
 }
 
-func UpdateCategory(id int, updatedCategory *Category) error {
-	result := configs.DB.Model(&Category{}).Where("id = ?", id).Updates(updatedCategory)
-	return result.Error
+// UpdateCategory applies the non-zero fields of updatedCategory plus the given
+// rate columns. Rates go through a map because a nil rate has to be written
+// as NULL to clear it, which struct updates skip.
+func UpdateCategory(id int, updatedCategory *Category, rates map[string]interface{}) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Category{}).Where("id = ?", id).Updates(updatedCategory).Error; err != nil {
+			return err
+		}
+
+		if len(rates) == 0 {
+			return nil
+		}
+		return tx.Model(&Category{}).Where("id = ?", id).Updates(rates).Error
+	})
 }
 
 func DeleteCategory(id int) error {
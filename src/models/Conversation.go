@@ -0,0 +1,165 @@
+package models
+
+import (
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Conversation struct {
+	gorm.Model
+	CustomerID    uint      `json:"customer_id" gorm:"index" validate:"required"`
+	Customer      Customer  `gorm:"foreignKey:CustomerID" validate:"-"`
+	SellerID      uint      `json:"seller_id" gorm:"index" validate:"required"`
+	Seller        Seller    `gorm:"foreignKey:SellerID" validate:"-"`
+	ProductID     *uint     `json:"product_id"`
+	Product       *Product  `gorm:"foreignKey:ProductID" validate:"-"`
+	LastMessageAt time.Time `json:"last_message_at"`
+}
+
+type ChatMessage struct {
+	gorm.Model
+	ConversationID uint       `json:"conversation_id" gorm:"index" validate:"required"`
+	SenderID       uint       `json:"sender_id" validate:"required"`
+	Body           string     `json:"body" validate:"required_without=Image,max=2000"`
+	Image          string     `json:"image" validate:"omitempty,url"`
+	ReadAt         *time.Time `json:"read_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+func SelectConversationsByCustomerId(customerId, limit, offset int) []*Conversation {
+	var conversations []*Conversation
+	configs.DB.Preload("Seller").Preload("Product").Order("last_message_at DESC").Limit(limit).Offset(offset).Where("customer_id = ?", customerId).Find(&conversations)
+	return conversations
+}
+
+func CountConversationsByCustomerId(customerId int) int64 {
+	var result int64
+	configs.DB.Table("conversations").Where("deleted_at IS NULL AND customer_id = ?", customerId).Count(&result)
+	return result
+}
+
+func SelectConversationsBySellerId(sellerId, limit, offset int) []*Conversation {
+	var conversations []*Conversation
+	configs.DB.Preload("Customer").Preload("Product").Order("last_message_at DESC").Limit(limit).Offset(offset).Where("seller_id = ?", sellerId).Find(&conversations)
+	return conversations
+}
+
+func CountConversationsBySellerId(sellerId int) int64 {
+	var result int64
+	configs.DB.Table("conversations").Where("deleted_at IS NULL AND seller_id = ?", sellerId).Count(&result)
+	return result
+}
+
+func SelectConversationById(id int) *Conversation {
+	var conversation Conversation
+	configs.DB.Preload("Customer").Preload("Seller").First(&conversation, "id = ?", id)
+	return &conversation
+}
+
+func SelectConversation(customerId, sellerId uint, productId *uint) *Conversation {
+	var conversation Conversation
+	query := configs.DB.Where("customer_id = ? AND seller_id = ?", customerId, sellerId)
+	if productId == nil {
+		query = query.Where("product_id IS NULL")
+	} else {
+		query = query.Where("product_id = ?", *productId)
+	}
+	query.First(&conversation)
+	return &conversation
+}
+
+func CreateConversation(conversation *Conversation) error {
+	result := configs.DB.Create(&conversation)
+	return result.Error
+}
+
+func SelectMessagesByConversationId(conversationId, limit, offset int) []*ChatMessage {
+	var messages []*ChatMessage
+	configs.DB.Order("id DESC").Limit(limit).Offset(offset).Where("conversation_id = ?", conversationId).Find(&messages)
+	return messages
+}
+
+// SelectMessagesAfter returns messages newer than afterId in send order, so a
+// reconnecting client can catch up from the last message it has seen.
+func SelectMessagesAfter(conversationId, afterId, limit int) []*ChatMessage {
+	var messages []*ChatMessage
+	configs.DB.Order("id ASC").Limit(limit).Where("conversation_id = ? AND id > ?", conversationId, afterId).Find(&messages)
+	return messages
+}
+
+func CountMessagesByConversationId(conversationId int) int64 {
+	var result int64
+	configs.DB.Table("chat_messages").Where("deleted_at IS NULL AND conversation_id = ?", conversationId).Count(&result)
+	return result
+}
+
+// CountUnreadMessagesByConversation counts unread messages for a page of
+// conversations in one query. Conversations without unread messages are
+// missing from the result.
+func CountUnreadMessagesByConversation(conversationIds []uint, userId uint) map[uint]int64 {
+	var rows []struct {
+		ConversationID uint
+		Count          int64
+	}
+	configs.DB.Table("chat_messages").
+		Select("conversation_id, COUNT(*) AS count").
+		Where("deleted_at IS NULL AND conversation_id IN ? AND sender_id <> ? AND read_at IS NULL", conversationIds, userId).
+		Group("conversation_id").
+		Scan(&rows)
+
+	result := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		result[row.ConversationID] = row.Count
+	}
+	return result
+}
+
+// ChatChannel is the Postgres channel chat events are announced on, so every
+// API process can write them to its own sockets.
+const ChatChannel = "chat"
+
+func SignalChatEvent(payload string) error {
+	result := configs.DB.Exec("SELECT pg_notify(?, ?)", ChatChannel, payload)
+	return result.Error
+}
+
+func SelectChatMessageById(id int) *ChatMessage {
+	var message ChatMessage
+	configs.DB.First(&message, "id = ?", id)
+	return &message
+}
+
+// CreateChatMessage stores the message and queues the notification to the
+// recipient, which runs after notifyAfter and is skipped if the message was
+// delivered live by then.
+func CreateChatMessage(message *ChatMessage, notifyAfter time.Duration) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&Conversation{}).Where("id = ?", message.ConversationID).Update("last_message_at", message.CreatedAt).Error; err != nil {
+			return err
+		}
+
+		return EnqueueJob(tx, JobChatMessage, ChatMessagePayload{MessageID: message.ID}, message.CreatedAt.Add(notifyAfter))
+	})
+}
+
+// MarkChatMessageDelivered records the first time a message reached an open
+// socket of its recipient.
+func MarkChatMessageDelivered(id uint, deliveredAt time.Time) error {
+	result := configs.DB.Model(&ChatMessage{}).Where("id = ? AND delivered_at IS NULL", id).Update("delivered_at", deliveredAt)
+	return result.Error
+}
+
+// MarkMessagesRead marks everything the other participant sent as read and
+// reports how many messages changed.
+func MarkMessagesRead(conversationId int, userId uint, readAt time.Time) (int64, error) {
+	result := configs.DB.Model(&ChatMessage{}).
+		Where("conversation_id = ? AND sender_id <> ? AND read_at IS NULL", conversationId, userId).
+		Update("read_at", readAt)
+	return result.RowsAffected, result.Error
+}
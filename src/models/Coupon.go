@@ -0,0 +1,162 @@
+package models
+
+import (
+	"errors"
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CouponType string
+
+const (
+	Percentage CouponType = "percentage"
+	Fixed      CouponType = "fixed"
+)
+
+type Coupon struct {
+	gorm.Model
+	Code             string     `json:"code" gorm:"uniqueIndex:idx_coupons_code_active,where:deleted_at IS NULL" validate:"required,alphanum,uppercase,max=30"`
+	Type             CouponType `gorm:"type:varchar(20)" json:"type" validate:"required,oneof=percentage fixed"`
+	Amount           Money      `json:"amount" validate:"required_if=Type fixed,gte=0"`
+	Percent          float64    `json:"percent" validate:"required_if=Type percentage,gte=0,lte=100"`
+	MinSpend         Money      `json:"min_spend" validate:"gte=0"`
+	MaxDiscount      Money      `json:"max_discount" validate:"gte=0"`
+	Currency         string     `gorm:"type:varchar(3);default:IDR" json:"currency" validate:"omitempty,len=3,uppercase"`
+	UsageLimit       int        `json:"usage_limit" validate:"gte=0"`
+	PerCustomerLimit int        `json:"per_customer_limit" validate:"gte=0"`
+	UsedCount        int        `json:"used_count" validate:"-"`
+	StartsAt         time.Time  `json:"starts_at" validate:"required"`
+	EndsAt           time.Time  `json:"ends_at" validate:"required,gtfield=StartsAt"`
+	CategoryID       *uint      `json:"category_id" gorm:"index"`
+	SellerID         *uint      `json:"seller_id" gorm:"index"`
+}
+
+type CouponRedemption struct {
+	gorm.Model
+	CouponID   uint  `json:"coupon_id" gorm:"index" validate:"required"`
+	CustomerID uint  `json:"customer_id" gorm:"index" validate:"required"`
+	PaymentID  uint  `json:"payment_id" gorm:"index"`
+	Discount   Money `json:"discount" validate:"gte=0"`
+}
+
+var (
+	ErrCouponUsageLimit    = errors.New("coupon usage limit reached")
+	ErrCouponCustomerLimit = errors.New("coupon usage limit per customer reached")
+	ErrCouponNotActive     = errors.New("coupon is not active")
+)
+
+func SelectAllCoupons(limit, offset int) []*Coupon {
+	var coupons []*Coupon
+	configs.DB.Order("created_at DESC").Limit(limit).Offset(offset).Find(&coupons)
+	return coupons
+}
+
+func CountCoupons() int64 {
+	var result int64
+	configs.DB.Table("coupons").Where("deleted_at IS NULL").Count(&result)
+	return result
+}
+
+func SelectCouponsBySellerId(sellerId, limit, offset int) []*Coupon {
+	var coupons []*Coupon
+	configs.DB.Order("created_at DESC").Limit(limit).Offset(offset).Where("seller_id = ?", sellerId).Find(&coupons)
+	return coupons
+}
+
+func CountCouponsBySellerId(sellerId int) int64 {
+	var result int64
+	configs.DB.Table("coupons").Where("deleted_at IS NULL AND seller_id = ?", sellerId).Count(&result)
+	return result
+}
+
+func SelectCouponById(id int) *Coupon {
+	var coupon Coupon
+	configs.DB.First(&coupon, "id = ?", id)
+	return &coupon
+}
+
+func SelectCouponByCode(code string) *Coupon {
+	var coupon Coupon
+	configs.DB.First(&coupon, "code = ?", code)
+	return &coupon
+}
+
+func CountCouponRedemptions(couponId, customerId int) int64 {
+	var result int64
+	configs.DB.Table("coupon_redemptions").Where("deleted_at IS NULL AND coupon_id = ? AND customer_id = ?", couponId, customerId).Count(&result)
+	return result
+}
+
+func CreateCoupon(coupon *Coupon) error {
+	result := configs.DB.Create(&coupon)
+	return result.Error
+}
+
+// UpdateCoupon replaces every editable field, so limits can be reset to 0 and
+// the category and seller scopes can be cleared.
+func UpdateCoupon(id int, updatedCoupon *Coupon) error {
+	result := configs.DB.Model(&Coupon{}).Where("id = ?", id).
+		Select("code", "type", "amount", "percent", "min_spend", "max_discount", "currency", "usage_limit", "per_customer_limit", "starts_at", "ends_at", "category_id", "seller_id").
+		Updates(updatedCoupon)
+	return result.Error
+}
+
+func DeleteCoupon(id int) error {
+	result := configs.DB.Delete(&Coupon{}, "id = ?", id)
+	return result.Error
+}
+
+// redeemCoupon records one use of the coupon for a payment. The coupon row is
+// locked until tx ends so concurrent checkouts cannot exceed the global or
+// per-customer usage limits.
+func redeemCoupon(tx *gorm.DB, redemption *CouponRedemption, now time.Time) error {
+	var coupon Coupon
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coupon, "id = ?", redemption.CouponID).Error; err != nil {
+		return err
+	}
+
+	if now.Before(coupon.StartsAt) || now.After(coupon.EndsAt) {
+		return ErrCouponNotActive
+	}
+
+	if coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit {
+		return ErrCouponUsageLimit
+	}
+
+	if coupon.PerCustomerLimit > 0 {
+		var used int64
+		if err := tx.Model(&CouponRedemption{}).Where("coupon_id = ? AND customer_id = ?", coupon.ID, redemption.CustomerID).Count(&used).Error; err != nil {
+			return err
+		}
+		if used >= int64(coupon.PerCustomerLimit) {
+			return ErrCouponCustomerLimit
+		}
+	}
+
+	if err := tx.Create(redemption).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&Coupon{}).Where("id = ?", coupon.ID).Update("used_count", gorm.Expr("used_count + 1")).Error
+}
+
+// releaseCoupon gives back the use of a coupon whose payment failed.
+func releaseCoupon(tx *gorm.DB, paymentId uint) error {
+	var redemptions []*CouponRedemption
+	if err := tx.Where("payment_id = ?", paymentId).Find(&redemptions).Error; err != nil {
+		return err
+	}
+
+	for _, redemption := range redemptions {
+		if err := tx.Delete(redemption).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Coupon{}).Where("id = ? AND used_count > 0", redemption.CouponID).Update("used_count", gorm.Expr("used_count - 1")).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
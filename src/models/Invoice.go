@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"gofiber-marketplace/src/configs"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Invoice is issued for every order when it is paid. Invoices of a seller
+// are numbered without gaps, and keep the seller details as they were at
+// the time so later profile changes do not alter issued invoices.
+type Invoice struct {
+	gorm.Model
+	OrderID       uint      `json:"order_id" gorm:"uniqueIndex" validate:"required"`
+	SellerID      uint      `json:"seller_id" gorm:"uniqueIndex:idx_invoices_seller_sequence" validate:"required"`
+	Sequence      int       `json:"sequence" gorm:"uniqueIndex:idx_invoices_seller_sequence"`
+	Number        string    `json:"number"`
+	SellerName    string    `json:"seller_name"`
+	SellerEmail   string    `json:"seller_email"`
+	SellerPhone   string    `json:"seller_phone"`
+	SellerAddress string    `json:"seller_address"`
+	IssuedAt      time.Time `json:"issued_at"`
+}
+
+func SelectInvoiceByOrderId(orderId int) *Invoice {
+	var invoice Invoice
+	configs.DB.First(&invoice, "order_id = ?", orderId)
+	return &invoice
+}
+
+// issueInvoice gives the order the seller's next invoice number. The seller
+// row is locked so concurrent payments cannot take the same number.
+func issueInvoice(tx *gorm.DB, order *Order, issuedAt time.Time) error {
+	var seller Seller
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("User").First(&seller, "id = ?", order.SellerID).Error; err != nil {
+		return err
+	}
+
+	var sequence int
+	if err := tx.Model(&Invoice{}).Unscoped().Select("COALESCE(MAX(sequence), 0)").Where("seller_id = ?", seller.ID).Scan(&sequence).Error; err != nil {
+		return err
+	}
+	sequence++
+
+	var address Address
+	if err := tx.Order("created_at ASC").Limit(1).Find(&address, "user_id = ?", seller.UserID).Error; err != nil {
+		return err
+	}
+	sellerAddress := seller.Location
+	if address.ID != 0 {
+		sellerAddress = strings.Join([]string{address.MainAddress, address.DetailAddress, address.City + " " + address.PostalCode}, ", ")
+	}
+
+	invoice := Invoice{
+		OrderID:       order.ID,
+		SellerID:      seller.ID,
+		Sequence:      sequence,
+		Number:        fmt.Sprintf("INV-%d-%06d", seller.ID, sequence),
+		SellerName:    seller.Name,
+		SellerEmail:   seller.User.Email,
+		SellerPhone:   seller.Phone,
+		SellerAddress: sellerAddress,
+		IssuedAt:      issuedAt,
+	}
+	return tx.Create(&invoice).Error
+}
@@ -0,0 +1,213 @@
+package models
+
+import (
+	"encoding/json"
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobDead    JobStatus = "dead"
+)
+
+const (
+	JobProductUpdated   = "product.updated"
+	JobProductImport    = "product.import"
+	JobQuestionAnswered = "question.answered"
+	JobChatMessage      = "chat.message"
+	JobOrderStatus      = "order.status"
+	JobReturnStatus     = "return.status"
+)
+
+type Job struct {
+	gorm.Model
+	Type        string     `gorm:"type:varchar(50);index" json:"type" validate:"required"`
+	Payload     string     `json:"payload"`
+	Status      JobStatus  `gorm:"type:varchar(20);default:pending;index" json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts" gorm:"default:5"`
+	RunAt       time.Time  `json:"run_at" gorm:"index"`
+	LockedAt    *time.Time `json:"locked_at"`
+	LastError   string     `json:"last_error"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+// ProductUpdatedPayload carries the values before the change so handlers can
+// tell restocks, price drops and low stock apart.
+type ProductUpdatedPayload struct {
+	ProductID     uint  `json:"product_id"`
+	PreviousPrice Money `json:"previous_price"`
+	PreviousStock int   `json:"previous_stock"`
+}
+
+// ProductImportPayload carries the parsed file, which is not stored anywhere
+// else.
+type ProductImportPayload struct {
+	ImportID uint           `json:"import_id"`
+	Columns  map[string]int `json:"columns"`
+	Records  [][]string     `json:"records"`
+}
+
+type QuestionAnsweredPayload struct {
+	AnswerID uint `json:"answer_id"`
+}
+
+type ChatMessagePayload struct {
+	MessageID uint `json:"message_id"`
+}
+
+type OrderStatusPayload struct {
+	OrderID uint        `json:"order_id"`
+	Status  OrderStatus `json:"status"`
+}
+
+type ReturnStatusPayload struct {
+	ReturnID uint         `json:"return_id"`
+	Status   ReturnStatus `json:"status"`
+}
+
+// EnqueueJob adds a job inside tx so it is only visible once the domain change
+// that produced it commits. Pass configs.DB when there is no surrounding
+// transaction.
+func EnqueueJob(tx *gorm.DB, jobType string, payload interface{}, runAt time.Time) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&Job{
+		Type:    jobType,
+		Payload: string(data),
+		Status:  JobPending,
+		RunAt:   runAt,
+	}).Error
+}
+
+// ClaimJob locks the oldest due job and marks it running. SKIP LOCKED lets
+// several workers and processes poll the same table without handing out a job
+// twice.
+func ClaimJob(now time.Time) (*Job, error) {
+	var job Job
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", JobPending, now).
+			Order("run_at ASC").Limit(1).Find(&job)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		job.Status = JobRunning
+		job.Attempts++
+		job.LockedAt = &now
+		return tx.Model(&Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status":    job.Status,
+			"attempts":  job.Attempts,
+			"locked_at": job.LockedAt,
+		}).Error
+	})
+	if err != nil || job.ID == 0 {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func CompleteJob(id uint, finishedAt time.Time) error {
+	result := configs.DB.Model(&Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      JobDone,
+		"locked_at":   nil,
+		"last_error":  "",
+		"finished_at": finishedAt,
+	})
+	return result.Error
+}
+
+func RetryJob(id uint, lastError string, runAt time.Time) error {
+	result := configs.DB.Model(&Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     JobPending,
+		"locked_at":  nil,
+		"last_error": lastError,
+		"run_at":     runAt,
+	})
+	return result.Error
+}
+
+func BuryJob(id uint, lastError string, finishedAt time.Time) error {
+	result := configs.DB.Model(&Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      JobDead,
+		"locked_at":   nil,
+		"last_error":  lastError,
+		"finished_at": finishedAt,
+	})
+	return result.Error
+}
+
+// ExtendJobLock refreshes the lock of a running job so ReleaseStaleJobs can
+// tell a long job from one whose worker died.
+func ExtendJobLock(id uint, now time.Time) error {
+	result := configs.DB.Model(&Job{}).Where("id = ? AND status = ?", id, JobRunning).Update("locked_at", now)
+	return result.Error
+}
+
+// ReleaseStaleJobs puts jobs back in the queue whose worker died while
+// running them, which shows as a lock that was not extended in time.
+func ReleaseStaleJobs(lockedBefore time.Time) (int64, error) {
+	result := configs.DB.Model(&Job{}).
+		Where("status = ? AND locked_at < ?", JobRunning, lockedBefore).
+		Updates(map[string]interface{}{"status": JobPending, "locked_at": nil})
+	return result.RowsAffected, result.Error
+}
+
+func SelectJobsByStatus(status JobStatus, limit, offset int) []*Job {
+	var jobs []*Job
+	configs.DB.Order("updated_at DESC").Limit(limit).Offset(offset).Where("status = ?", status).Find(&jobs)
+	return jobs
+}
+
+func CountJobsByStatus(status JobStatus) int64 {
+	var result int64
+	configs.DB.Table("jobs").Where("deleted_at IS NULL AND status = ?", status).Count(&result)
+	return result
+}
+
+func SelectJobById(id int) *Job {
+	var job Job
+	configs.DB.First(&job, "id = ?", id)
+	return &job
+}
+
+// DeleteFinishedJobs removes completed jobs that finished before the given
+// time. Dead jobs are kept for inspection and requeueing.
+func DeleteFinishedJobs(finishedBefore time.Time) (int64, error) {
+	result := configs.DB.Unscoped().Where("status = ? AND finished_at < ?", JobDone, finishedBefore).Delete(&Job{})
+	return result.RowsAffected, result.Error
+}
+
+// TryAdvisoryLock runs fn while holding a transaction-scoped advisory lock on
+// name. When another process holds the lock, fn is skipped.
+func TryAdvisoryLock(name string, fn func() error) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(hashtext(?))", name).Scan(&locked).Error; err != nil || !locked {
+			return err
+		}
+		return fn()
+	})
+}
+
+func RequeueDeadJob(id int, runAt time.Time) error {
+	result := configs.DB.Model(&Job{}).Where("id = ? AND status = ?", id, JobDead).Updates(map[string]interface{}{
+		"status":      JobPending,
+		"attempts":    0,
+		"run_at":      runAt,
+		"finished_at": nil,
+	})
+	return result.Error
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LedgerEntryType string
+
+const (
+	SaleCredit      LedgerEntryType = "sale_credit"
+	CommissionDebit LedgerEntryType = "commission_debit"
+	RefundDebit     LedgerEntryType = "refund_debit"
+	PayoutDebit     LedgerEntryType = "payout_debit"
+
+	CommissionCredit LedgerEntryType = "commission_credit"
+	SaleClearing     LedgerEntryType = "sale_clearing"
+	RefundClearing   LedgerEntryType = "refund_clearing"
+	PayoutClearing   LedgerEntryType = "payout_clearing"
+)
+
+type LedgerAccount string
+
+const (
+	SellerAccount   LedgerAccount = "seller"
+	PlatformAccount LedgerAccount = "platform"
+)
+
+// LedgerEntry is one movement on a seller's balance or on the platform's
+// side of it. Credits are positive and debits negative, so a balance is the
+// sum of its account's entries, and the entries of every transaction sum to
+// zero across both accounts. Platform entries keep the seller they relate to.
+// Entries are in the seller's currency, which is fixed once the seller has
+// listings, so a seller balance never mixes currencies. Funds only become
+// available for payout once AvailableAt has passed.
+type LedgerEntry struct {
+	gorm.Model
+	SellerID    uint            `json:"seller_id" gorm:"index" validate:"required"`
+	Account     LedgerAccount   `gorm:"type:varchar(20);default:seller;index" json:"account" validate:"required,oneof=seller platform"`
+	Type        LedgerEntryType `gorm:"type:varchar(20)" json:"type" validate:"required,oneof=sale_credit commission_debit refund_debit payout_debit commission_credit sale_clearing refund_clearing payout_clearing"`
+	Amount      Money           `json:"amount" validate:"required"`
+	Currency    string          `gorm:"type:varchar(3)" json:"currency" validate:"required,len=3"`
+	Reference   string          `json:"reference"`
+	AvailableAt time.Time       `json:"available_at" gorm:"index"`
+}
+
+type SellerBalance struct {
+	Pending   Money  `json:"pending"`
+	Available Money  `json:"available"`
+	Currency  string `json:"currency"`
+}
+
+func SelectLedgerEntriesBySellerId(sellerId, limit, offset int) []*LedgerEntry {
+	var entries []*LedgerEntry
+	configs.DB.Order("created_at DESC").Limit(limit).Offset(offset).Where("seller_id = ? AND account = ?", sellerId, SellerAccount).Find(&entries)
+	return entries
+}
+
+func CountLedgerEntriesBySellerId(sellerId int) int64 {
+	var result int64
+	configs.DB.Table("ledger_entries").Where("deleted_at IS NULL AND seller_id = ? AND account = ?", sellerId, SellerAccount).Count(&result)
+	return result
+}
+
+func SelectSellerBalance(sellerId int, currency string, now time.Time) *SellerBalance {
+	return selectSellerBalance(configs.DB, sellerId, currency, now)
+}
+
+func selectSellerBalance(tx *gorm.DB, sellerId int, currency string, now time.Time) *SellerBalance {
+	balance := SellerBalance{Currency: currency}
+	tx.Model(&LedgerEntry{}).
+		Select("COALESCE(SUM(CASE WHEN available_at > ? THEN amount ELSE 0 END), 0) AS pending, "+
+			"COALESCE(SUM(CASE WHEN available_at <= ? THEN amount ELSE 0 END), 0) AS available", now, now).
+		Where("seller_id = ? AND account = ? AND currency = ?", sellerId, SellerAccount, currency).
+		Scan(&balance)
+	return &balance
+}
+
+// recordSale credits a seller for a paid order and debits the platform
+// commission on it. The platform books the commission it earns and the
+// payment it holds for the seller. All entries share the hold period.
+func recordSale(tx *gorm.DB, order *Order, availableAt time.Time) error {
+	var commission Money
+	for _, item := range order.Items {
+		commission += (item.Amount - item.Discount).Percent(item.CommissionRate)
+	}
+
+	entries := []LedgerEntry{
+		{SellerID: order.SellerID, Account: SellerAccount, Type: SaleCredit, Amount: order.Total, Currency: order.Currency, Reference: order.Number, AvailableAt: availableAt},
+		{SellerID: order.SellerID, Account: SellerAccount, Type: CommissionDebit, Amount: -commission, Currency: order.Currency, Reference: order.Number, AvailableAt: availableAt},
+		{SellerID: order.SellerID, Account: PlatformAccount, Type: CommissionCredit, Amount: commission, Currency: order.Currency, Reference: order.Number, AvailableAt: availableAt},
+		{SellerID: order.SellerID, Account: PlatformAccount, Type: SaleClearing, Amount: -order.Total, Currency: order.Currency, Reference: order.Number, AvailableAt: availableAt},
+	}
+	return tx.Create(&entries).Error
+}
+
+// recordRefund debits a seller for money given back to the customer and
+// returns the platform commission that was taken on it.
+func recordRefund(tx *gorm.DB, sellerId uint, amount, commission Money, currency, reference string, refundedAt time.Time) error {
+	entries := []LedgerEntry{
+		{SellerID: sellerId, Account: SellerAccount, Type: RefundDebit, Amount: -amount, Currency: currency, Reference: reference, AvailableAt: refundedAt},
+		{SellerID: sellerId, Account: SellerAccount, Type: CommissionDebit, Amount: commission, Currency: currency, Reference: reference, AvailableAt: refundedAt},
+		{SellerID: sellerId, Account: PlatformAccount, Type: CommissionCredit, Amount: -commission, Currency: currency, Reference: reference, AvailableAt: refundedAt},
+		{SellerID: sellerId, Account: PlatformAccount, Type: RefundClearing, Amount: amount, Currency: currency, Reference: reference, AvailableAt: refundedAt},
+	}
+	return tx.Create(&entries).Error
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in hundredths of the currency unit, stored as an integer
+// so sums, discounts and taxes do not drift. It is read and written as a
+// plain decimal number in JSON, e.g. 15000.5.
+type Money int64
+
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// maxMoney keeps amounts within the range a float64 holds exactly.
+const maxMoney = 1 << 53
+
+func NewMoney(amount float64) Money {
+	return Money(math.Round(amount * 100))
+}
+
+func ParseMoney(value string) (Money, error) {
+	amount, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) || math.Abs(amount*100) >= maxMoney {
+		return 0, ErrInvalidMoney
+	}
+	return NewMoney(amount), nil
+}
+
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+func (m Money) Times(quantity int) Money {
+	return m * Money(quantity)
+}
+
+// Percent returns rate percent of the amount, rounded to the nearest
+// hundredth.
+func (m Money) Percent(rate float64) Money {
+	return Money(math.Round(float64(m) * rate / 100))
+}
+
+// Convert applies an exchange rate, rounding to the nearest hundredth.
+func (m Money) Convert(rate float64) Money {
+	return Money(math.Round(float64(m) * rate))
+}
+
+func (m Money) String() string {
+	return strconv.FormatFloat(m.Float64(), 'f', -1, 64)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts both numbers and numeric strings, so clients that
+// quote amounts keep working.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "null" || value == "" {
+		return nil
+	}
+
+	amount, err := ParseMoney(value)
+	if err != nil {
+		return err
+	}
+	*m = amount
+	return nil
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"gofiber-marketplace/src/configs"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationType string
+
+const (
+	NotificationProductAlert     NotificationType = "product_alert"
+	NotificationChatMessage      NotificationType = "chat_message"
+	NotificationQuestionAnswered NotificationType = "question_answered"
+	NotificationOrderStatus      NotificationType = "order_status"
+	NotificationReturnStatus     NotificationType = "return_status"
+)
+
+type Notification struct {
+	gorm.Model
+	UserID  uint             `json:"user_id" gorm:"index" validate:"required"`
+	Type    NotificationType `gorm:"type:varchar(30)" json:"type" validate:"required"`
+	Title   string           `json:"title" validate:"required"`
+	Message string           `json:"message"`
+	ReadAt  *time.Time       `json:"read_at"`
+}
+
+func SelectNotificationsByUserId(userId int, unreadOnly bool, limit, offset int) []*Notification {
+	var notifications []*Notification
+	query := configs.DB.Order("created_at DESC").Limit(limit).Offset(offset).Where("user_id = ?", userId)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	query.Find(&notifications)
+	return notifications
+}
+
+func CountNotificationsByUserId(userId int, unreadOnly bool) int64 {
+	var result int64
+	query := configs.DB.Table("notifications").Where("deleted_at IS NULL AND user_id = ?", userId)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	query.Count(&result)
+	return result
+}
+
+func SelectNotificationById(id int) *Notification {
+	var notification Notification
+	configs.DB.First(&notification, "id = ?", id)
+	return &notification
+}
+
+// NotificationChannel is the Postgres channel new notification IDs are
+// announced on, so every API process can push them to its own streams.
+const NotificationChannel = "notifications"
+
+func SignalNotification(id uint) error {
+	result := configs.DB.Exec("SELECT pg_notify(?, ?)", NotificationChannel, strconv.Itoa(int(id)))
+	return result.Error
+}
+
+func CreateNotification(notification *Notification) error {
+	result := configs.DB.Create(&notification)
+	return result.Error
+}
+
+func MarkNotificationRead(id int, readAt time.Time) error {
+	result := configs.DB.Model(&Notification{}).Where("id = ? AND read_at IS NULL", id).Update("read_at", readAt)
+	return result.Error
+}
+
+func MarkAllNotificationsRead(userId int, readAt time.Time) (int64, error) {
+	result := configs.DB.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userId).Update("read_at", readAt)
+	return result.RowsAffected, result.Error
+}
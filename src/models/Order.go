@@ -0,0 +1,408 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"gofiber-marketplace/src/configs"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrderStatus string
+
+const (
+	OrderPendingPayment OrderStatus = "pending_payment"
+	OrderPaid           OrderStatus = "paid"
+	OrderShipped        OrderStatus = "shipped"
+	OrderDelivered      OrderStatus = "delivered"
+	OrderCompleted      OrderStatus = "completed"
+	OrderCancelled      OrderStatus = "cancelled"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentPending PaymentStatus = "pending"
+	PaymentPaid    PaymentStatus = "paid"
+	PaymentFailed  PaymentStatus = "failed"
+)
+
+// Payment is one charge for a checkout. A checkout with products from
+// several sellers is paid at once but split into one order per seller. The
+// coupon and loyalty points are redeemed when the orders are placed and given
+// back when the payment fails.
+type Payment struct {
+	gorm.Model
+	CustomerID     uint          `json:"customer_id" gorm:"index" validate:"required"`
+	Provider       string        `gorm:"type:varchar(30)" json:"provider" validate:"required"`
+	ProviderRef    string        `json:"provider_ref"`
+	Amount         Money         `json:"amount" validate:"gte=0"`
+	Currency       string        `gorm:"type:varchar(3)" json:"currency" validate:"required,len=3"`
+	CouponID       *uint         `json:"coupon_id"`
+	CouponDiscount Money         `json:"coupon_discount"`
+	PointsUsed     int           `json:"points_used"`
+	PointsDiscount Money         `json:"points_discount"`
+	Status         PaymentStatus `gorm:"type:varchar(20);default:pending;index" json:"status"`
+	FailureReason  string        `json:"failure_reason"`
+	PaidAt         *time.Time    `json:"paid_at"`
+	Orders         []Order       `json:"orders"`
+}
+
+// Order keeps a copy of the shipping address and of every price it was
+// placed at, so later edits to addresses, products or promotions do not
+// change what the customer bought.
+type Order struct {
+	gorm.Model
+	Number             string      `json:"number" gorm:"uniqueIndex:idx_orders_number,where:number <> ''"`
+	PaymentID          uint        `json:"payment_id" gorm:"index"`
+	CustomerID         uint        `json:"customer_id" gorm:"index" validate:"required"`
+	Customer           Customer    `gorm:"foreignKey:CustomerID" validate:"-"`
+	SellerID           uint        `json:"seller_id" gorm:"index:idx_orders_seller_paid_at,priority:1" validate:"required"`
+	Seller             Seller      `gorm:"foreignKey:SellerID" validate:"-"`
+	RecipientName      string      `json:"recipient_name"`
+	RecipientPhone     string      `json:"recipient_phone"`
+	ShippingAddress    string      `json:"shipping_address"`
+	ShippingDetail     string      `json:"shipping_detail"`
+	ShippingCity       string      `json:"shipping_city"`
+	ShippingPostalCode string      `json:"shipping_postal_code"`
+	ShippingProvider   string      `json:"shipping_provider"`
+	ShippingCourier    string      `json:"shipping_courier"`
+	ShippingService    string      `json:"shipping_service"`
+	Currency           string      `gorm:"type:varchar(3)" json:"currency" validate:"required,len=3"`
+	Subtotal           Money       `json:"subtotal"`
+	Discount           Money       `json:"discount"`
+	Tax                Money       `json:"tax"`
+	ShippingCost       Money       `json:"shipping_cost"`
+	Total              Money       `json:"total"`
+	Status             OrderStatus `gorm:"type:varchar(20);default:pending_payment;index" json:"status"`
+	PaidAt             *time.Time  `json:"paid_at" gorm:"index:idx_orders_seller_paid_at,priority:2"`
+	CancelledAt        *time.Time  `json:"cancelled_at"`
+	ShippedAt          *time.Time  `json:"shipped_at"`
+	DeliveredAt        *time.Time  `json:"delivered_at" gorm:"index"`
+	CompletedAt        *time.Time  `json:"completed_at"`
+	Items              []OrderItem `json:"items"`
+}
+
+// OrderItem amounts follow helpers.CartLine: Amount is UnitPrice times
+// Quantity, Discount its share of the order discounts, and Net, Tax and
+// Gross are worked out on what is left. PromotionID is set when the item
+// sold at a sale price, and CommissionRate is the platform's cut as it stood
+// for the item's category at checkout.
+type OrderItem struct {
+	gorm.Model
+	OrderID        uint    `json:"order_id" gorm:"index"`
+	ProductID      uint    `json:"product_id" gorm:"index" validate:"required"`
+	PromotionID    *uint   `json:"promotion_id"`
+	Name           string  `json:"name"`
+	Image          string  `json:"image"`
+	Quantity       int     `json:"quantity" validate:"required,gt=0"`
+	UnitPrice      Money   `json:"unit_price"`
+	Amount         Money   `json:"amount"`
+	Discount       Money   `json:"discount"`
+	TaxRate        float64 `json:"tax_rate"`
+	CommissionRate float64 `json:"commission_rate"`
+	Net            Money   `json:"net"`
+	Tax            Money   `json:"tax"`
+	Gross          Money   `json:"gross"`
+}
+
+var (
+	ErrPaymentProcessed   = errors.New("payment is already processed")
+	ErrProductUnavailable = errors.New("product is no longer available")
+)
+
+// Reference identifies the payment to the payment provider and in the
+// loyalty history.
+func (p *Payment) Reference() string {
+	return fmt.Sprintf("PAY-%d", p.ID)
+}
+
+func SelectOrderById(id int) *Order {
+	var order Order
+	configs.DB.Preload("Items").Preload("Seller").Preload("Customer").First(&order, "id = ?", id)
+	return &order
+}
+
+func SelectOrdersByCustomerId(customerId int, status string, limit, offset int) []*Order {
+	var orders []*Order
+	query := configs.DB.Preload("Items").Preload("Seller").Order("created_at DESC").Limit(limit).Offset(offset).Where("customer_id = ?", customerId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Find(&orders)
+	return orders
+}
+
+func CountOrdersByCustomerId(customerId int, status string) int64 {
+	var result int64
+	query := configs.DB.Table("orders").Where("deleted_at IS NULL AND customer_id = ?", customerId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&result)
+	return result
+}
+
+func SelectOrdersBySellerId(sellerId int, status string, limit, offset int) []*Order {
+	var orders []*Order
+	query := configs.DB.Preload("Items").Preload("Customer").Order("created_at DESC").Limit(limit).Offset(offset).Where("seller_id = ?", sellerId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Find(&orders)
+	return orders
+}
+
+func CountOrdersBySellerId(sellerId int, status string) int64 {
+	var result int64
+	query := configs.DB.Table("orders").Where("deleted_at IS NULL AND seller_id = ?", sellerId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&result)
+	return result
+}
+
+func SelectPaymentById(id int) *Payment {
+	var payment Payment
+	configs.DB.Preload("Orders.Items").First(&payment, "id = ?", id)
+	return &payment
+}
+
+// SelectExpiredPayments returns payments still pending from before the given
+// time, whose reserved stock should go back on sale.
+func SelectExpiredPayments(createdBefore time.Time, limit int) []*Payment {
+	var payments []*Payment
+	configs.DB.Order("created_at ASC").Limit(limit).Where("status = ? AND created_at < ?", PaymentPending, createdBefore).Find(&payments)
+	return payments
+}
+
+// PlaceOrders creates the payment and its orders in one transaction, takes
+// the ordered quantities out of stock and claims sale stock, the coupon and
+// loyalty points, so two checkouts cannot use up the same unit, sale item,
+// coupon use or points. Products are locked in id order to keep concurrent checkouts from
+// deadlocking.
+func PlaceOrders(payment *Payment, orders []*Order) error {
+	quantities := make(map[uint]int)
+	var productIds []uint
+	for _, order := range orders {
+		for _, item := range order.Items {
+			if _, ok := quantities[item.ProductID]; !ok {
+				productIds = append(productIds, item.ProductID)
+			}
+			quantities[item.ProductID] += item.Quantity
+		}
+	}
+	sort.Slice(productIds, func(a, b int) bool {
+		return productIds[a] < productIds[b]
+	})
+
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for _, productId := range productIds {
+			var product Product
+			result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Limit(1).Find(&product, "id = ?", productId)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 || product.Status != ListingActive {
+				return fmt.Errorf("product with ID %d: %w", productId, ErrProductUnavailable)
+			}
+			if product.Stock < quantities[productId] {
+				return fmt.Errorf("product with ID %d: %w", productId, ErrInsufficientStock)
+			}
+
+			if err := tx.Model(&Product{}).Where("id = ?", productId).Update("stock", gorm.Expr("stock - ?", quantities[productId])).Error; err != nil {
+				return err
+			}
+			if err := EnqueueJob(tx, JobProductUpdated, ProductUpdatedPayload{ProductID: product.ID, PreviousPrice: product.Price, PreviousStock: product.Stock}, now); err != nil {
+				return err
+			}
+		}
+
+		payment.Status = PaymentPending
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+
+		if payment.CouponID != nil {
+			redemption := CouponRedemption{
+				CouponID:   *payment.CouponID,
+				CustomerID: payment.CustomerID,
+				PaymentID:  payment.ID,
+				Discount:   payment.CouponDiscount,
+			}
+			if err := redeemCoupon(tx, &redemption, now); err != nil {
+				return err
+			}
+		}
+
+		if payment.PointsUsed > 0 {
+			if err := redeemPoints(tx, payment.CustomerID, payment.PointsUsed, payment.Reference(), now); err != nil {
+				return err
+			}
+		}
+
+		var claims []*PromotionClaim
+		for _, order := range orders {
+			order.PaymentID = payment.ID
+			order.Status = OrderPendingPayment
+			if err := tx.Create(order).Error; err != nil {
+				return err
+			}
+
+			for _, item := range order.Items {
+				if item.PromotionID != nil {
+					claims = append(claims, &PromotionClaim{
+						PromotionID: *item.PromotionID,
+						CustomerID:  payment.CustomerID,
+						PaymentID:   payment.ID,
+						Quantity:    item.Quantity,
+						UnitPrice:   item.UnitPrice,
+					})
+				}
+			}
+
+			order.Number = fmt.Sprintf("ORD-%s-%06d", order.CreatedAt.Format("20060102"), order.ID)
+			if err := tx.Model(order).Update("number", order.Number).Error; err != nil {
+				return err
+			}
+		}
+
+		sort.Slice(claims, func(a, b int) bool {
+			return claims[a].PromotionID < claims[b].PromotionID
+		})
+		for _, claim := range claims {
+			if err := claimPromotion(tx, claim, now); err != nil {
+				return fmt.Errorf("promotion with ID %d: %w", claim.PromotionID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// CompletePayment marks the payment and its orders paid once the provider
+// has charged the customer, credits each seller for their order and issues
+// its invoice. The credit becomes available for payout after holdPeriod.
+func CompletePayment(id uint, providerRef string, paidAt time.Time, holdPeriod time.Duration) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var payment Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if payment.Status != PaymentPending {
+			return ErrPaymentProcessed
+		}
+
+		if err := tx.Model(&Payment{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"status":       PaymentPaid,
+			"provider_ref": providerRef,
+			"paid_at":      paidAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		var orders []*Order
+		// Orders go in seller order, so concurrent payments lock sellers for
+		// invoice numbering in the same order.
+		if err := tx.Preload("Items").Order("seller_id ASC").Where("payment_id = ?", id).Find(&orders).Error; err != nil {
+			return err
+		}
+		for _, order := range orders {
+			if err := tx.Model(&Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+				"status":  OrderPaid,
+				"paid_at": paidAt,
+			}).Error; err != nil {
+				return err
+			}
+			if err := recordSale(tx, order, paidAt.Add(holdPeriod)); err != nil {
+				return err
+			}
+			if err := issueInvoice(tx, order, paidAt); err != nil {
+				return err
+			}
+			if err := EnqueueJob(tx, JobOrderStatus, OrderStatusPayload{OrderID: order.ID, Status: OrderPaid}, paidAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FailPayment cancels a payment that was declined or never completed and
+// puts the stock of its orders back.
+func FailPayment(id uint, reason string, failedAt time.Time) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var payment Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if payment.Status != PaymentPending {
+			return ErrPaymentProcessed
+		}
+
+		if err := tx.Model(&Payment{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"status":         PaymentFailed,
+			"failure_reason": reason,
+		}).Error; err != nil {
+			return err
+		}
+
+		var orders []*Order
+		if err := tx.Preload("Items").Where("payment_id = ?", id).Find(&orders).Error; err != nil {
+			return err
+		}
+		for _, order := range orders {
+			if err := cancelOrder(tx, order, failedAt); err != nil {
+				return err
+			}
+		}
+		if err := releaseCoupon(tx, id); err != nil {
+			return err
+		}
+		if err := returnPoints(tx, payment.Reference()); err != nil {
+			return err
+		}
+		return releasePromotions(tx, id)
+	})
+}
+
+func cancelOrder(tx *gorm.DB, order *Order, cancelledAt time.Time) error {
+	if err := tx.Model(&Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"status":       OrderCancelled,
+		"cancelled_at": cancelledAt,
+	}).Error; err != nil {
+		return err
+	}
+	return restockItems(tx, order.Items, cancelledAt)
+}
+
+// restockItems puts quantities back on sale. The product update job lets
+// back-in-stock alerts and webhooks see the change.
+func restockItems(tx *gorm.DB, items []OrderItem, now time.Time) error {
+	sort.Slice(items, func(a, b int) bool {
+		return items[a].ProductID < items[b].ProductID
+	})
+
+	for _, item := range items {
+		var product Product
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Limit(1).Find(&product, "id = ?", item.ProductID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		if err := tx.Model(&Product{}).Where("id = ?", item.ProductID).Update("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+			return err
+		}
+		if err := EnqueueJob(tx, JobProductUpdated, ProductUpdatedPayload{ProductID: product.ID, PreviousPrice: product.Price, PreviousStock: product.Stock}, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PayoutStatus string
+
+const (
+	PayoutPending  PayoutStatus = "pending"
+	PayoutApproved PayoutStatus = "approved"
+	PayoutRejected PayoutStatus = "rejected"
+)
+
+type Payout struct {
+	gorm.Model
+	SellerID    uint         `json:"seller_id" gorm:"index" validate:"required"`
+	Seller      Seller       `gorm:"foreignKey:SellerID" validate:"-"`
+	Amount      Money        `json:"amount" validate:"required,gt=0"`
+	Currency    string       `gorm:"type:varchar(3)" json:"currency"`
+	Status      PayoutStatus `gorm:"type:varchar(20);default:pending" json:"status" validate:"oneof=pending approved rejected"`
+	Note        string       `json:"note"`
+	ProcessedAt *time.Time   `json:"processed_at"`
+}
+
+var (
+	ErrInsufficientBalance = errors.New("insufficient available balance")
+	ErrPayoutProcessed     = errors.New("payout already processed")
+)
+
+func SelectPayoutsBySellerId(sellerId, limit, offset int) []*Payout {
+	var payouts []*Payout
+	configs.DB.Order("created_at DESC").Limit(limit).Offset(offset).Where("seller_id = ?", sellerId).Find(&payouts)
+	return payouts
+}
+
+func CountPayoutsBySellerId(sellerId int) int64 {
+	var result int64
+	configs.DB.Table("payouts").Where("deleted_at IS NULL AND seller_id = ?", sellerId).Count(&result)
+	return result
+}
+
+func SelectPayoutsByStatus(status PayoutStatus, limit, offset int) []*Payout {
+	var payouts []*Payout
+	configs.DB.Preload("Seller").Order("created_at ASC").Limit(limit).Offset(offset).Where("status = ?", status).Find(&payouts)
+	return payouts
+}
+
+func CountPayoutsByStatus(status PayoutStatus) int64 {
+	var result int64
+	configs.DB.Table("payouts").Where("deleted_at IS NULL AND status = ?", status).Count(&result)
+	return result
+}
+
+func SelectPayoutById(id int) *Payout {
+	var payout Payout
+	configs.DB.First(&payout, "id = ?", id)
+	return &payout
+}
+
+// CreatePayout requests a payout against the seller's available balance,
+// minus payouts that are still waiting for approval. The seller row is locked
+// so two requests cannot both spend the same funds. Payouts are in the
+// seller's currency, like the balance.
+func CreatePayout(payout *Payout) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var seller Seller
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&seller, "id = ?", payout.SellerID).Error; err != nil {
+			return err
+		}
+
+		payout.Currency = seller.Currency
+
+		var requested Money
+		if err := tx.Model(&Payout{}).Select("COALESCE(SUM(amount), 0)").Where("seller_id = ? AND status = ? AND currency = ?", payout.SellerID, PayoutPending, payout.Currency).Scan(&requested).Error; err != nil {
+			return err
+		}
+
+		balance := selectSellerBalance(tx, int(payout.SellerID), payout.Currency, time.Now())
+		if payout.Amount > balance.Available-requested {
+			return ErrInsufficientBalance
+		}
+
+		payout.Status = PayoutPending
+		return tx.Create(payout).Error
+	})
+}
+
+func ApprovePayout(id int) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var payout Payout
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payout, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		if payout.Status != PayoutPending {
+			return ErrPayoutProcessed
+		}
+
+		now := time.Now()
+		reference := fmt.Sprintf("payout:%d", payout.ID)
+		entries := []LedgerEntry{
+			{SellerID: payout.SellerID, Account: SellerAccount, Type: PayoutDebit, Amount: -payout.Amount, Currency: payout.Currency, Reference: reference, AvailableAt: now},
+			{SellerID: payout.SellerID, Account: PlatformAccount, Type: PayoutClearing, Amount: payout.Amount, Currency: payout.Currency, Reference: reference, AvailableAt: now},
+		}
+		if err := tx.Create(&entries).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&payout).Updates(map[string]interface{}{
+			"status":       PayoutApproved,
+			"processed_at": now,
+		}).Error
+	})
+}
+
+func RejectPayout(id int, note string) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var payout Payout
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payout, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		if payout.Status != PayoutPending {
+			return ErrPayoutProcessed
+		}
+
+		return tx.Model(&payout).Updates(map[string]interface{}{
+			"status":       PayoutRejected,
+			"note":         note,
+			"processed_at": time.Now(),
+		}).Error
+	})
+}
@@ -0,0 +1,213 @@
+package models
+
+import (
+	"errors"
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PointEntryType string
+
+const (
+	PointsEarned   PointEntryType = "earned"
+	PointsRedeemed PointEntryType = "redeemed"
+	PointsExpired  PointEntryType = "expired"
+)
+
+// PointEntry is one movement on a customer's loyalty balance. Earned points
+// are positive and redeemed or expired points negative, so the balance is
+// the sum of the entries. Earned entries track their unspent points in
+// Remaining: redemptions use the points closest to expiry first, and expiry
+// only takes what was never spent.
+type PointEntry struct {
+	gorm.Model
+	CustomerID uint           `json:"customer_id" gorm:"index" validate:"required"`
+	Type       PointEntryType `gorm:"type:varchar(20)" json:"type" validate:"required,oneof=earned redeemed expired"`
+	Points     int            `json:"points" validate:"required"`
+	Remaining  int            `json:"remaining" validate:"gte=0"`
+	Reference  string         `json:"reference"`
+	ExpiresAt  *time.Time     `json:"expires_at" gorm:"index"`
+}
+
+var ErrInsufficientPoints = errors.New("insufficient points")
+
+func SelectPointEntriesByCustomerId(customerId, limit, offset int) []*PointEntry {
+	var entries []*PointEntry
+	configs.DB.Order("created_at DESC").Limit(limit).Offset(offset).Where("customer_id = ?", customerId).Find(&entries)
+	return entries
+}
+
+func CountPointEntriesByCustomerId(customerId int) int64 {
+	var result int64
+	configs.DB.Table("point_entries").Where("deleted_at IS NULL AND customer_id = ?", customerId).Count(&result)
+	return result
+}
+
+// SelectPointBalance returns the points the customer can spend now: the
+// unspent part of unexpired earned entries. Summing all entries would also
+// count points that have expired but were not written off yet, which
+// redeemPoints refuses to spend.
+func SelectPointBalance(customerId int) int {
+	var result int
+	configs.DB.Model(&PointEntry{}).
+		Select("COALESCE(SUM(remaining), 0)").
+		Where("customer_id = ? AND type = ? AND remaining > 0 AND expires_at > ?", customerId, PointsEarned, time.Now()).
+		Scan(&result)
+	return result
+}
+
+// SelectExpiringPoints returns the unspent points that are still valid now
+// but expire before the given time.
+func SelectExpiringPoints(customerId int, now, before time.Time) int {
+	var result int
+	configs.DB.Model(&PointEntry{}).
+		Select("COALESCE(SUM(remaining), 0)").
+		Where("customer_id = ? AND type = ? AND remaining > 0 AND expires_at > ? AND expires_at <= ?", customerId, PointsEarned, now, before).
+		Scan(&result)
+	return result
+}
+
+// EarnPoints credits points that expire at expiresAt. Points are earned once
+// per reference, so a retried job does not award them twice.
+func EarnPoints(customerId uint, points int, reference string, expiresAt time.Time) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		return earnPoints(tx, customerId, points, reference, expiresAt)
+	})
+}
+
+func earnPoints(tx *gorm.DB, customerId uint, points int, reference string, expiresAt time.Time) error {
+	if points <= 0 {
+		return nil
+	}
+
+	var customer Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, "id = ?", customerId).Error; err != nil {
+		return err
+	}
+
+	var existing int64
+	if err := tx.Model(&PointEntry{}).Where("customer_id = ? AND type = ? AND reference = ?", customerId, PointsEarned, reference).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	return tx.Create(&PointEntry{
+		CustomerID: customerId,
+		Type:       PointsEarned,
+		Points:     points,
+		Remaining:  points,
+		Reference:  reference,
+		ExpiresAt:  &expiresAt,
+	}).Error
+}
+
+// redeemPoints spends points from the customer's unexpired earned entries,
+// soonest to expire first. The customer row is locked until tx ends so two
+// checkouts cannot spend the same points. The redeemed entry keeps the latest
+// expiry it spent, which is what returned points get.
+func redeemPoints(tx *gorm.DB, customerId uint, points int, reference string, now time.Time) error {
+	var customer Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, "id = ?", customerId).Error; err != nil {
+		return err
+	}
+
+	var earned []*PointEntry
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("expires_at ASC, id ASC").
+		Where("customer_id = ? AND type = ? AND remaining > 0 AND expires_at > ?", customerId, PointsEarned, now).
+		Find(&earned).Error; err != nil {
+		return err
+	}
+
+	available := 0
+	for _, entry := range earned {
+		available += entry.Remaining
+	}
+	if available < points {
+		return ErrInsufficientPoints
+	}
+
+	left := points
+	var expiresAt *time.Time
+	for _, entry := range earned {
+		if left == 0 {
+			break
+		}
+
+		used := min(entry.Remaining, left)
+		if err := tx.Model(entry).Update("remaining", entry.Remaining-used).Error; err != nil {
+			return err
+		}
+		left -= used
+		expiresAt = entry.ExpiresAt
+	}
+
+	return tx.Create(&PointEntry{
+		CustomerID: customerId,
+		Type:       PointsRedeemed,
+		Points:     -points,
+		Reference:  reference,
+		ExpiresAt:  expiresAt,
+	}).Error
+}
+
+// returnPoints gives back the points redeemed under reference, for a payment
+// that failed. They expire when the latest of the spent points would have.
+func returnPoints(tx *gorm.DB, reference string) error {
+	var redeemed []*PointEntry
+	if err := tx.Where("type = ? AND reference = ?", PointsRedeemed, reference).Find(&redeemed).Error; err != nil {
+		return err
+	}
+
+	for _, entry := range redeemed {
+		if err := tx.Create(&PointEntry{
+			CustomerID: entry.CustomerID,
+			Type:       PointsEarned,
+			Points:     -entry.Points,
+			Remaining:  -entry.Points,
+			Reference:  "returned " + reference,
+			ExpiresAt:  entry.ExpiresAt,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpirePoints writes off the unspent part of every earned entry that has
+// expired and reports how many entries were expired.
+func ExpirePoints(now time.Time) (int64, error) {
+	var expired int64
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		var earned []*PointEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("type = ? AND remaining > 0 AND expires_at <= ?", PointsEarned, now).
+			Limit(500).
+			Find(&earned).Error; err != nil {
+			return err
+		}
+
+		for _, entry := range earned {
+			if err := tx.Create(&PointEntry{
+				CustomerID: entry.CustomerID,
+				Type:       PointsExpired,
+				Points:     -entry.Remaining,
+				Reference:  entry.Reference,
+			}).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Model(entry).Update("remaining", 0).Error; err != nil {
+				return err
+			}
+		}
+
+		expired = int64(len(earned))
+		return nil
+	})
+	return expired, err
+}
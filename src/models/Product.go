@@ -2,8 +2,10 @@ package models
 
 import (
 	"gofiber-marketplace/src/configs"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductCondition string
@@ -13,27 +15,45 @@ const (
 	Used ProductCondition = "used"
 )
 
+type ListingStatus string
+
+const (
+	ListingDraft         ListingStatus = "draft"
+	ListingPendingReview ListingStatus = "pending_review"
+	ListingActive        ListingStatus = "active"
+	ListingRejected      ListingStatus = "rejected"
+	ListingArchived      ListingStatus = "archived"
+)
+
 type Product struct {
 	gorm.Model
-	Name        string           `json:"name" validate:"required"`
-	Price       float64          `json:"price" validate:"required,gt=0"`
-	Stock       int              `json:"stock" validate:"required,gt=0"`
-	Image       string           `json:"image" validate:"required"`
-	Size        uint             `json:"size" validate:"required,gt=0"`
-	Color       string           `json:"color" validate:"required,iscolor"`
-	Rating      uint             `json:"rating" gorm:"default:0"`
-	Description string           `json:"description" validate:"required"`
-	Condition   ProductCondition `gorm:"type:product_condition;default:new" json:"condition" validate:"oneof=new used"`
-	CategoryID  uint             `json:"category_id" validate:"required"`
-	Category    Category         `gorm:"foreignKey:CategoryID" validate:"-"`
-	SellerID    uint             `json:"seller_id" validate:"required"`
-	Seller      Seller           `gorm:"foreignKey:SellerID" validate:"-"`
+	Name           string           `json:"name" validate:"required"`
+	Price          Money            `json:"price" validate:"required,gt=0"`
+	Currency       string           `gorm:"type:varchar(3);default:IDR" json:"currency" validate:"omitempty,len=3,uppercase"`
+	Stock          int              `json:"stock" validate:"gte=0"`
+	Image          string           `json:"image" validate:"required"`
+	Size           uint             `json:"size" validate:"required,gt=0"`
+	Color          string           `json:"color" validate:"required,iscolor"`
+	Rating         uint             `json:"rating" gorm:"default:0"`
+	Description    string           `json:"description" validate:"required"`
+	Condition      ProductCondition `gorm:"type:product_condition;default:new" json:"condition" validate:"oneof=new used"`
+	CategoryID     uint             `json:"category_id" validate:"required"`
+	Category       Category         `gorm:"foreignKey:CategoryID" validate:"-"`
+	SellerID       uint             `json:"seller_id" validate:"required"`
+	Seller         Seller           `gorm:"foreignKey:SellerID" validate:"-"`
+	Status         ListingStatus    `gorm:"type:varchar(20);default:active;index" json:"status" validate:"omitempty,oneof=draft pending_review active rejected archived"`
+	ModerationNote string           `json:"moderation_note"`
+	ReviewedAt     *time.Time       `json:"reviewed_at"`
+	Weight         int              `json:"weight" validate:"gte=0"`
+	Length         int              `json:"length" validate:"gte=0"`
+	Width          int              `json:"width" validate:"gte=0"`
+	Height         int              `json:"height" validate:"gte=0"`
 }
 
 func SelectAllProducts(keyword, sort string, limit, offset int) []*Product {
 	var products []*Product
 	keyword = "%" + keyword + "%"
-	configs.DB.Preload("Category").Preload("Seller").Order(sort).Limit(limit).Offset(offset).Where("name ILIKE ?", keyword).Find(&products)
+	configs.DB.Preload("Category").Preload("Seller").Order(sort).Limit(limit).Offset(offset).Where("status = ? AND name ILIKE ?", ListingActive, keyword).Find(&products)
 	return products
 }
 
@@ -46,17 +66,122 @@ func SelectProductById(id int) *Product {
 func CountData(keyword string) int64 {
 	var result int64
 	keyword = "%" + keyword + "%"
-	configs.DB.Table("products").Where("deleted_at IS NULL AND name ILIKE ?", keyword).Count(&result)
+	configs.DB.Table("products").Where("deleted_at IS NULL AND status = ? AND name ILIKE ?", ListingActive, keyword).Count(&result)
+	return result
+}
+
+func SelectProductsByCategoryId(categoryId int, sort string, limit, offset int) []*Product {
+	var products []*Product
+	configs.DB.Preload("Seller").Order(sort).Limit(limit).Offset(offset).Where("status = ? AND category_id = ?", ListingActive, categoryId).Find(&products)
+	return products
+}
+
+func CountProductsByCategoryId(categoryId int) int64 {
+	var result int64
+	configs.DB.Table("products").Where("deleted_at IS NULL AND status = ? AND category_id = ?", ListingActive, categoryId).Count(&result)
+	return result
+}
+
+func SelectProductsBySellerId(sellerId int, sort string, limit, offset int) []*Product {
+	var products []*Product
+	configs.DB.Preload("Category").Order(sort).Limit(limit).Offset(offset).Where("status = ? AND seller_id = ?", ListingActive, sellerId).Find(&products)
+	return products
+}
+
+func SelectAllProductsBySellerId(sellerId int) []*Product {
+	var products []*Product
+	configs.DB.Preload("Category").Order("id ASC").Where("seller_id = ?", sellerId).Find(&products)
+	return products
+}
+
+func CountProductsBySellerId(sellerId int) int64 {
+	var result int64
+	configs.DB.Table("products").Where("deleted_at IS NULL AND status = ? AND seller_id = ?", ListingActive, sellerId).Count(&result)
+	return result
+}
+
+func SelectSellerCatalog(sellerId int, status ListingStatus, sort string, limit, offset int) []*Product {
+	var products []*Product
+	query := configs.DB.Preload("Category").Order(sort).Limit(limit).Offset(offset).Where("seller_id = ?", sellerId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Find(&products)
+	return products
+}
+
+func CountSellerCatalog(sellerId int, status ListingStatus) int64 {
+	var result int64
+	query := configs.DB.Table("products").Where("deleted_at IS NULL AND seller_id = ?", sellerId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&result)
+	return result
+}
+
+func SelectProductsByStatus(status ListingStatus, limit, offset int) []*Product {
+	var products []*Product
+	configs.DB.Preload("Category").Preload("Seller").Order("updated_at ASC").Limit(limit).Offset(offset).Where("status = ?", status).Find(&products)
+	return products
+}
+
+func CountProductsByStatus(status ListingStatus) int64 {
+	var result int64
+	configs.DB.Table("products").Where("deleted_at IS NULL AND status = ?", status).Count(&result)
 	return result
 }
 
 func CreateProduct(product *Product) error {
-	result := configs.DB.Create(&product)
-	return result.Error
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+
+		return createPriceHistory(tx, product.ID, product.Price)
+	})
+}
+
+// UpdateProduct writes the non-zero fields of updatedProduct. Stock is written
+// on its own when withStock is set, so a product can sell out without every
+// update that leaves stock out zeroing it.
+func UpdateProduct(id int, updatedProduct *Product, withStock bool) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var product Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&Product{}).Where("id = ?", id).Updates(updatedProduct).Error; err != nil {
+			return err
+		}
+
+		if withStock {
+			if err := tx.Model(&Product{}).Where("id = ?", id).Update("stock", updatedProduct.Stock).Error; err != nil {
+				return err
+			}
+		}
+
+		if updatedProduct.Price != 0 && updatedProduct.Price != product.Price {
+			if err := createPriceHistory(tx, product.ID, updatedProduct.Price); err != nil {
+				return err
+			}
+		}
+
+		return EnqueueJob(tx, JobProductUpdated, ProductUpdatedPayload{
+			ProductID:     product.ID,
+			PreviousPrice: product.Price,
+			PreviousStock: product.Stock,
+		}, time.Now())
+	})
 }
 
-func UpdateProduct(id int, updatedProduct *Product) error {
-	result := configs.DB.Model(&Product{}).Where("id = ?", id).Updates(updatedProduct)
+func UpdateProductStatus(id int, status ListingStatus, note string, reviewedAt *time.Time) error {
+	result := configs.DB.Model(&Product{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          status,
+		"moderation_note": note,
+		"reviewed_at":     reviewedAt,
+	})
 	return result.Error
 }
 
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ProductAlertType string
+
+const (
+	BackInStock ProductAlertType = "back_in_stock"
+	PriceDrop   ProductAlertType = "price_drop"
+)
+
+type ProductAlert struct {
+	gorm.Model
+	CustomerID  uint             `json:"customer_id" validate:"required"`
+	Customer    Customer         `gorm:"foreignKey:CustomerID" validate:"-"`
+	ProductID   uint             `json:"product_id" validate:"required"`
+	Product     Product          `gorm:"foreignKey:ProductID" validate:"-"`
+	Type        ProductAlertType `gorm:"type:varchar(20)" json:"type" validate:"required,oneof=back_in_stock price_drop"`
+	TargetPrice Money            `json:"target_price" validate:"gte=0"`
+	NotifiedAt  *time.Time       `json:"notified_at"`
+}
+
+func SelectProductAlertsByCustomerId(customerId int) []*ProductAlert {
+	var alerts []*ProductAlert
+	configs.DB.Preload("Product").Order("created_at DESC").Where("customer_id = ?", customerId).Find(&alerts)
+	return alerts
+}
+
+func SelectProductAlertById(id int) *ProductAlert {
+	var alert ProductAlert
+	configs.DB.First(&alert, "id = ?", id)
+	return &alert
+}
+
+func SelectPendingProductAlert(customerId, productId int, alertType ProductAlertType) *ProductAlert {
+	var alert ProductAlert
+	configs.DB.First(&alert, "customer_id = ? AND product_id = ? AND type = ? AND notified_at IS NULL", customerId, productId, alertType)
+	return &alert
+}
+
+func SelectPendingProductAlertsByProductId(productId int) []*ProductAlert {
+	var alerts []*ProductAlert
+	configs.DB.Preload("Customer").Where("product_id = ? AND notified_at IS NULL", productId).Find(&alerts)
+	return alerts
+}
+
+func CreateProductAlert(alert *ProductAlert) error {
+	result := configs.DB.Create(&alert)
+	return result.Error
+}
+
+func MarkProductAlertNotified(id int) error {
+	result := configs.DB.Model(&ProductAlert{}).Where("id = ?", id).Update("notified_at", time.Now())
+	return result.Error
+}
+
+func DeleteProductAlert(id int) error {
+	result := configs.DB.Delete(&ProductAlert{}, "id = ?", id)
+	return result.Error
+}
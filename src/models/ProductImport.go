@@ -0,0 +1,61 @@
+package models
+
+import (
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ProductImportStatus string
+
+const (
+	ImportPending    ProductImportStatus = "pending"
+	ImportProcessing ProductImportStatus = "processing"
+	ImportCompleted  ProductImportStatus = "completed"
+	ImportFailed     ProductImportStatus = "failed"
+)
+
+type ProductImportRowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+type ProductImport struct {
+	gorm.Model
+	SellerID    uint                    `json:"seller_id" gorm:"index" validate:"required"`
+	FileName    string                  `json:"file_name"`
+	Status      ProductImportStatus     `gorm:"type:varchar(20);default:pending" json:"status"`
+	TotalRows   int                     `json:"total_rows"`
+	CreatedRows int                     `json:"created_rows"`
+	UpdatedRows int                     `json:"updated_rows"`
+	FailedRows  int                     `json:"failed_rows"`
+	RowErrors   []ProductImportRowError `json:"row_errors" gorm:"type:jsonb;serializer:json"`
+}
+
+func SelectProductImportById(id int) *ProductImport {
+	var productImport ProductImport
+	configs.DB.First(&productImport, "id = ?", id)
+	return &productImport
+}
+
+// CreateProductImport stores the import and enqueues the job that processes
+// its rows, so an import is never created without a worker picking it up.
+func CreateProductImport(productImport *ProductImport, columns map[string]int, records [][]string) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&productImport).Error; err != nil {
+			return err
+		}
+
+		return EnqueueJob(tx, JobProductImport, ProductImportPayload{
+			ImportID: productImport.ID,
+			Columns:  columns,
+			Records:  records,
+		}, time.Now())
+	})
+}
+
+func SaveProductImport(productImport *ProductImport) error {
+	result := configs.DB.Save(productImport)
+	return result.Error
+}
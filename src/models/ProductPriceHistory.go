@@ -0,0 +1,54 @@
+package models
+
+import (
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ProductPriceHistory struct {
+	gorm.Model
+	ProductID uint  `json:"product_id" gorm:"index" validate:"required"`
+	Price     Money `json:"price" validate:"required,gt=0"`
+}
+
+func SelectPriceHistoriesByProductId(productId, limit, offset int) []*ProductPriceHistory {
+	var histories []*ProductPriceHistory
+	configs.DB.Order("created_at DESC").Limit(limit).Offset(offset).Where("product_id = ?", productId).Find(&histories)
+	return histories
+}
+
+func CountPriceHistoriesByProductId(productId int) int64 {
+	var result int64
+	configs.DB.Table("product_price_histories").Where("deleted_at IS NULL AND product_id = ?", productId).Count(&result)
+	return result
+}
+
+// SelectLowestPriceSince returns the lowest price that was in effect for the
+// product at any point since the given time, including the price that was
+// already active at that moment. It returns 0 when no history is recorded.
+func SelectLowestPriceSince(productId int, since time.Time) Money {
+	var result struct {
+		Price Money
+	}
+	configs.DB.Table("product_price_histories").
+		Select("COALESCE(MIN(price), 0) AS price").
+		Where("deleted_at IS NULL AND product_id = ?", productId).
+		Where("created_at >= ? OR id = (?)", since,
+			configs.DB.Table("product_price_histories").
+				Select("id").
+				Where("deleted_at IS NULL AND product_id = ? AND created_at < ?", productId, since).
+				Order("created_at DESC").
+				Limit(1)).
+		Scan(&result)
+	return result.Price
+}
+
+func createPriceHistory(tx *gorm.DB, productId uint, price Money) error {
+	result := tx.Create(&ProductPriceHistory{
+		ProductID: productId,
+		Price:     price,
+	})
+	return result.Error
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"errors"
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProductQuestion struct {
+	gorm.Model
+	ProductID  uint            `json:"product_id" gorm:"index" validate:"required"`
+	Product    Product         `gorm:"foreignKey:ProductID" validate:"-"`
+	CustomerID uint            `json:"customer_id" gorm:"index" validate:"required"`
+	Customer   Customer        `gorm:"foreignKey:CustomerID" validate:"-"`
+	Question   string          `json:"question" validate:"required,max=500"`
+	Upvotes    int             `json:"upvotes" gorm:"default:0"`
+	Answers    []ProductAnswer `gorm:"foreignKey:QuestionID" validate:"-"`
+}
+
+type ProductAnswer struct {
+	gorm.Model
+	QuestionID uint   `json:"question_id" gorm:"index" validate:"required"`
+	SellerID   uint   `json:"seller_id" gorm:"index" validate:"required"`
+	Seller     Seller `gorm:"foreignKey:SellerID" validate:"-"`
+	Answer     string `json:"answer" validate:"required,max=1000"`
+	Upvotes    int    `json:"upvotes" gorm:"default:0"`
+}
+
+// ProductQAVote records who upvoted which question or answer so a user can
+// only count once per entry.
+type ProductQAVote struct {
+	gorm.Model
+	UserID     uint   `json:"user_id" gorm:"uniqueIndex:idx_qa_vote_user_target"`
+	TargetType string `json:"target_type" gorm:"type:varchar(20);uniqueIndex:idx_qa_vote_user_target"`
+	TargetID   uint   `json:"target_id" gorm:"uniqueIndex:idx_qa_vote_user_target"`
+}
+
+var ErrAlreadyUpvoted = errors.New("already upvoted")
+
+func SelectQuestionsByProductId(productId, limit, offset int) []*ProductQuestion {
+	var questions []*ProductQuestion
+	configs.DB.Preload("Customer").
+		Preload("Answers", func(db *gorm.DB) *gorm.DB {
+			return db.Order("upvotes DESC, created_at ASC")
+		}).
+		Preload("Answers.Seller").
+		Order("upvotes DESC, created_at DESC").Limit(limit).Offset(offset).
+		Where("product_id = ?", productId).Find(&questions)
+	return questions
+}
+
+func CountQuestionsByProductId(productId int) int64 {
+	var result int64
+	configs.DB.Table("product_questions").Where("deleted_at IS NULL AND product_id = ?", productId).Count(&result)
+	return result
+}
+
+func SelectQuestionById(id int) *ProductQuestion {
+	var question ProductQuestion
+	configs.DB.Preload("Product").Preload("Customer").First(&question, "id = ?", id)
+	return &question
+}
+
+func SelectAnswerById(id int) *ProductAnswer {
+	var answer ProductAnswer
+	configs.DB.First(&answer, "id = ?", id)
+	return &answer
+}
+
+func CreateQuestion(question *ProductQuestion) error {
+	result := configs.DB.Create(&question)
+	return result.Error
+}
+
+// CreateAnswer stores the answer and queues the notification to the asker in
+// the same transaction.
+func CreateAnswer(answer *ProductAnswer) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&answer).Error; err != nil {
+			return err
+		}
+
+		return EnqueueJob(tx, JobQuestionAnswered, QuestionAnsweredPayload{AnswerID: answer.ID}, time.Now())
+	})
+}
+
+func UpvoteQuestion(id, userId uint) error {
+	return upvote("product_questions", id, userId)
+}
+
+func UpvoteAnswer(id, userId uint) error {
+	return upvote("product_answers", id, userId)
+}
+
+func upvote(table string, id, userId uint) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		vote := ProductQAVote{UserID: userId, TargetType: table, TargetID: id}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&vote)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAlreadyUpvoted
+		}
+
+		return tx.Table(table).Where("id = ?", id).UpdateColumn("upvotes", gorm.Expr("upvotes + 1")).Error
+	})
+}
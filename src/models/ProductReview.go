@@ -0,0 +1,86 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProductReview is a customer's rating of a product they bought. Each
+// completed order allows one review per product in it.
+type ProductReview struct {
+	gorm.Model
+	ProductID  uint     `json:"product_id" gorm:"index;uniqueIndex:idx_product_reviews_order_product" validate:"required"`
+	OrderID    uint     `json:"order_id" gorm:"uniqueIndex:idx_product_reviews_order_product" validate:"required"`
+	CustomerID uint     `json:"customer_id" gorm:"index" validate:"required"`
+	Customer   Customer `gorm:"foreignKey:CustomerID" validate:"-"`
+	Rating     uint     `json:"rating" validate:"required,min=1,max=5"`
+	Comment    string   `json:"comment" validate:"max=1000"`
+}
+
+var (
+	ErrReviewNotAllowed = errors.New("product was not bought in a completed order")
+	ErrAlreadyReviewed  = errors.New("product is already reviewed for every completed order")
+)
+
+func SelectReviewsByProductId(productId, limit, offset int) []*ProductReview {
+	var reviews []*ProductReview
+	configs.DB.Preload("Customer").Order("created_at DESC").Limit(limit).Offset(offset).Where("product_id = ?", productId).Find(&reviews)
+	return reviews
+}
+
+func CountReviewsByProductId(productId int) int64 {
+	var result int64
+	configs.DB.Table("product_reviews").Where("deleted_at IS NULL AND product_id = ?", productId).Count(&result)
+	return result
+}
+
+// CreateReview attaches the review to the customer's oldest completed order
+// of the product that has no review yet, updates the product's rating to
+// the rounded average of its reviews and awards the review points.
+func CreateReview(review *ProductReview, points int, pointsExpireAt time.Time) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var product Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, "id = ?", review.ProductID).Error; err != nil {
+			return err
+		}
+
+		bought := tx.Table("orders").
+			Joins("JOIN order_items ON order_items.order_id = orders.id AND order_items.deleted_at IS NULL").
+			Where("orders.deleted_at IS NULL AND orders.customer_id = ? AND orders.status = ? AND order_items.product_id = ?", review.CustomerID, OrderCompleted, review.ProductID).
+			Session(&gorm.Session{})
+
+		var orderIds []uint
+		if err := bought.
+			Where("NOT EXISTS (SELECT 1 FROM product_reviews WHERE product_reviews.order_id = orders.id AND product_reviews.product_id = order_items.product_id AND product_reviews.deleted_at IS NULL)").
+			Order("orders.completed_at ASC").Limit(1).
+			Pluck("orders.id", &orderIds).Error; err != nil {
+			return err
+		}
+		if len(orderIds) == 0 {
+			var completed int64
+			if err := bought.Count(&completed).Error; err != nil {
+				return err
+			}
+			if completed > 0 {
+				return ErrAlreadyReviewed
+			}
+			return ErrReviewNotAllowed
+		}
+
+		review.OrderID = orderIds[0]
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&Product{}).Where("id = ?", product.ID).
+			Update("rating", gorm.Expr("(SELECT ROUND(AVG(rating)) FROM product_reviews WHERE product_id = ? AND deleted_at IS NULL)", product.ID)).Error; err != nil {
+			return err
+		}
+		return earnPoints(tx, review.CustomerID, points, fmt.Sprintf("REV-%d", review.ID), pointsExpireAt)
+	})
+}
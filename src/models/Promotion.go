@@ -0,0 +1,169 @@
+package models
+
+import (
+	"errors"
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Promotion struct {
+	gorm.Model
+	SellerID         uint      `json:"seller_id" gorm:"index" validate:"required"`
+	ProductID        uint      `json:"product_id" gorm:"index" validate:"required"`
+	Product          Product   `gorm:"foreignKey:ProductID" validate:"-"`
+	SalePrice        Money     `json:"sale_price" validate:"required,gt=0"`
+	StartsAt         time.Time `json:"starts_at" validate:"required"`
+	EndsAt           time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+	PerCustomerLimit int       `json:"per_customer_limit" validate:"gte=0"`
+	PromoStock       int       `json:"promo_stock" validate:"gte=0"`
+	SoldCount        int       `json:"sold_count" validate:"-"`
+}
+
+type PromotionClaim struct {
+	gorm.Model
+	PromotionID uint  `json:"promotion_id" gorm:"index" validate:"required"`
+	CustomerID  uint  `json:"customer_id" gorm:"index" validate:"required"`
+	PaymentID   uint  `json:"payment_id" gorm:"index"`
+	Quantity    int   `json:"quantity" validate:"required,gt=0"`
+	UnitPrice   Money `json:"unit_price" validate:"required,gt=0"`
+}
+
+var (
+	ErrPromotionNotActive     = errors.New("promotion is not active")
+	ErrPromotionSoldOut       = errors.New("promotion stock is sold out")
+	ErrPromotionCustomerLimit = errors.New("promotion quantity limit per customer reached")
+	ErrInsufficientStock      = errors.New("insufficient stock")
+)
+
+func SelectPromotionsBySellerId(sellerId, limit, offset int) []*Promotion {
+	var promotions []*Promotion
+	configs.DB.Preload("Product").Order("starts_at DESC").Limit(limit).Offset(offset).Where("seller_id = ?", sellerId).Find(&promotions)
+	return promotions
+}
+
+func CountPromotionsBySellerId(sellerId int) int64 {
+	var result int64
+	configs.DB.Table("promotions").Where("deleted_at IS NULL AND seller_id = ?", sellerId).Count(&result)
+	return result
+}
+
+func SelectPromotionById(id int) *Promotion {
+	var promotion Promotion
+	configs.DB.First(&promotion, "id = ?", id)
+	return &promotion
+}
+
+func SelectActivePromotionByProductId(productId int, at time.Time) *Promotion {
+	var promotion Promotion
+	configs.DB.Where("product_id = ? AND starts_at <= ? AND ends_at > ?", productId, at, at).
+		Where("promo_stock = 0 OR sold_count < promo_stock").
+		Order("sale_price ASC").
+		First(&promotion)
+	return &promotion
+}
+
+func SelectActivePromotionsByProductIds(productIds []uint, at time.Time) map[uint]*Promotion {
+	var promotions []*Promotion
+	configs.DB.Where("product_id IN ? AND starts_at <= ? AND ends_at > ?", productIds, at, at).
+		Where("promo_stock = 0 OR sold_count < promo_stock").
+		Order("sale_price DESC").
+		Find(&promotions)
+
+	result := make(map[uint]*Promotion, len(promotions))
+	for _, promotion := range promotions {
+		result[promotion.ProductID] = promotion
+	}
+	return result
+}
+
+func CountOverlappingPromotions(productId int, startsAt, endsAt time.Time, excludeId int) int64 {
+	var result int64
+	configs.DB.Table("promotions").
+		Where("deleted_at IS NULL AND product_id = ? AND id <> ? AND starts_at < ? AND ends_at > ?", productId, excludeId, endsAt, startsAt).
+		Count(&result)
+	return result
+}
+
+func CreatePromotion(promotion *Promotion) error {
+	result := configs.DB.Create(&promotion)
+	return result.Error
+}
+
+// UpdatePromotion replaces every editable field, so the per-customer limit
+// and promo stock can be reset to 0 (unlimited).
+func UpdatePromotion(id int, updatedPromotion *Promotion) error {
+	result := configs.DB.Model(&Promotion{}).Where("id = ?", id).
+		Select("sale_price", "starts_at", "ends_at", "per_customer_limit", "promo_stock").
+		Updates(updatedPromotion)
+	return result.Error
+}
+
+func DeletePromotion(id int) error {
+	result := configs.DB.Delete(&Promotion{}, "id = ?", id)
+	return result.Error
+}
+
+// claimPromotion takes sale stock for an order item at the price the
+// customer was charged. The promotion is re-checked under a row lock, so a
+// sale that ended, sold out or changed price while the customer was checking
+// out is rejected instead of honoured.
+func claimPromotion(tx *gorm.DB, claim *PromotionClaim, now time.Time) error {
+	var promotion Promotion
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promotion, "id = ?", claim.PromotionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPromotionNotActive
+	} else if err != nil {
+		return err
+	}
+
+	var product Product
+	if err := tx.First(&product, "id = ?", promotion.ProductID).Error; err != nil {
+		return err
+	}
+
+	if now.Before(promotion.StartsAt) || !now.Before(promotion.EndsAt) ||
+		promotion.SalePrice != claim.UnitPrice || promotion.SalePrice >= product.Price {
+		return ErrPromotionNotActive
+	}
+
+	if promotion.PromoStock > 0 && promotion.SoldCount+claim.Quantity > promotion.PromoStock {
+		return ErrPromotionSoldOut
+	}
+
+	if promotion.PerCustomerLimit > 0 {
+		var claimed int64
+		if err := tx.Model(&PromotionClaim{}).Select("COALESCE(SUM(quantity), 0)").Where("promotion_id = ? AND customer_id = ?", promotion.ID, claim.CustomerID).Scan(&claimed).Error; err != nil {
+			return err
+		}
+		if int(claimed)+claim.Quantity > promotion.PerCustomerLimit {
+			return ErrPromotionCustomerLimit
+		}
+	}
+
+	if err := tx.Create(claim).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&Promotion{}).Where("id = ?", promotion.ID).Update("sold_count", gorm.Expr("sold_count + ?", claim.Quantity)).Error
+}
+
+// releasePromotions puts the sale stock claimed for a failed payment back.
+func releasePromotions(tx *gorm.DB, paymentId uint) error {
+	var claims []*PromotionClaim
+	if err := tx.Where("payment_id = ?", paymentId).Order("promotion_id ASC").Find(&claims).Error; err != nil {
+		return err
+	}
+
+	for _, claim := range claims {
+		if err := tx.Delete(claim).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Promotion{}).Where("id = ?", claim.PromotionID).Update("sold_count", gorm.Expr("GREATEST(sold_count - ?, 0)", claim.Quantity)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReturnStatus string
+
+const (
+	ReturnRequested ReturnStatus = "requested"
+	ReturnAccepted  ReturnStatus = "accepted"
+	ReturnRejected  ReturnStatus = "rejected"
+	ReturnShipped   ReturnStatus = "shipped"
+	ReturnReceived  ReturnStatus = "received"
+	ReturnRefunded  ReturnStatus = "refunded"
+)
+
+// openReturnStatuses are the statuses of a return that is still in progress,
+// which keeps its order from completing.
+var openReturnStatuses = []ReturnStatus{ReturnRequested, ReturnAccepted, ReturnShipped, ReturnReceived}
+
+// ReturnRequest asks the seller to take back items of a delivered order.
+// Amount is what the customer paid for the returned items; the seller may
+// refund less (RefundAmount) when the items come back damaged.
+type ReturnRequest struct {
+	gorm.Model
+	OrderID      uint         `json:"order_id" gorm:"index" validate:"required"`
+	Order        Order        `gorm:"foreignKey:OrderID" validate:"-"`
+	CustomerID   uint         `json:"customer_id" gorm:"index" validate:"required"`
+	SellerID     uint         `json:"seller_id" gorm:"index" validate:"required"`
+	Reason       string       `json:"reason" validate:"required,max=500"`
+	Photos       []string     `json:"photos" gorm:"type:jsonb;serializer:json" validate:"max=5,dive,url"`
+	Status       ReturnStatus `gorm:"type:varchar(20);default:requested;index" json:"status"`
+	Note         string       `json:"note"`
+	Currency     string       `gorm:"type:varchar(3)" json:"currency"`
+	Amount       Money        `json:"amount"`
+	RefundAmount Money        `json:"refund_amount"`
+	RefundRef    string       `json:"refund_ref"`
+	ReviewedAt   *time.Time   `json:"reviewed_at"`
+	ReceivedAt   *time.Time   `json:"received_at"`
+	RefundedAt   *time.Time   `json:"refunded_at"`
+	Items        []ReturnItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// ReturnItem is part of an order item being returned. Amount and Commission
+// are the item's paid value and platform commission for Quantity units.
+type ReturnItem struct {
+	gorm.Model
+	ReturnRequestID uint  `json:"return_request_id" gorm:"index"`
+	OrderItemID     uint  `json:"order_item_id" gorm:"index" validate:"required"`
+	ProductID       uint  `json:"product_id"`
+	Quantity        int   `json:"quantity" validate:"required,gt=0"`
+	Amount          Money `json:"amount"`
+	Commission      Money `json:"commission"`
+}
+
+var (
+	ErrReturnProcessed = errors.New("return is already processed")
+	ErrReturnQuantity  = errors.New("quantity exceeds what can still be returned")
+	ErrReturnOpen      = errors.New("order has a return in progress")
+)
+
+// Reference identifies the return to the payment provider and in the ledger.
+func (r *ReturnRequest) Reference() string {
+	return fmt.Sprintf("RET-%d", r.ID)
+}
+
+func SelectReturnRequestById(id int) *ReturnRequest {
+	var returnRequest ReturnRequest
+	configs.DB.Preload("Items").Preload("Order").First(&returnRequest, "id = ?", id)
+	return &returnRequest
+}
+
+func SelectReturnRequestsByCustomerId(customerId int, status string, limit, offset int) []*ReturnRequest {
+	var returnRequests []*ReturnRequest
+	query := configs.DB.Preload("Items").Preload("Order").Order("created_at DESC").Limit(limit).Offset(offset).Where("customer_id = ?", customerId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Find(&returnRequests)
+	return returnRequests
+}
+
+func CountReturnRequestsByCustomerId(customerId int, status string) int64 {
+	var result int64
+	query := configs.DB.Table("return_requests").Where("deleted_at IS NULL AND customer_id = ?", customerId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&result)
+	return result
+}
+
+func SelectReturnRequestsBySellerId(sellerId int, status string, limit, offset int) []*ReturnRequest {
+	var returnRequests []*ReturnRequest
+	query := configs.DB.Preload("Items").Preload("Order").Order("created_at DESC").Limit(limit).Offset(offset).Where("seller_id = ?", sellerId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Find(&returnRequests)
+	return returnRequests
+}
+
+func CountReturnRequestsBySellerId(sellerId int, status string) int64 {
+	var result int64
+	query := configs.DB.Table("return_requests").Where("deleted_at IS NULL AND seller_id = ?", sellerId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&result)
+	return result
+}
+
+// SelectRefundedAmount returns what was refunded on the order's returns.
+func SelectRefundedAmount(orderId int) Money {
+	var result Money
+	configs.DB.Model(&ReturnRequest{}).
+		Select("COALESCE(SUM(refund_amount), 0)").
+		Where("order_id = ? AND status = ?", orderId, ReturnRefunded).
+		Scan(&result)
+	return result
+}
+
+// CreateReturnRequest opens a return on a delivered order. Each item may be
+// returned up to the quantity bought, less what other returns that were not
+// rejected already cover.
+func CreateReturnRequest(returnRequest *ReturnRequest) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var order Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&order, "id = ?", returnRequest.OrderID).Error; err != nil {
+			return err
+		}
+		if order.Status != OrderDelivered {
+			return ErrOrderStatus
+		}
+
+		var returned []struct {
+			OrderItemID uint
+			Quantity    int
+		}
+		if err := tx.Table("return_items").
+			Select("return_items.order_item_id, SUM(return_items.quantity) AS quantity").
+			Joins("JOIN return_requests ON return_requests.id = return_items.return_request_id AND return_requests.deleted_at IS NULL").
+			Where("return_items.deleted_at IS NULL AND return_requests.order_id = ? AND return_requests.status <> ?", order.ID, ReturnRejected).
+			Group("return_items.order_item_id").
+			Scan(&returned).Error; err != nil {
+			return err
+		}
+
+		returnRequest.CustomerID = order.CustomerID
+		returnRequest.SellerID = order.SellerID
+		returnRequest.Currency = order.Currency
+		returnRequest.Status = ReturnRequested
+		returnRequest.Amount = 0
+		for i := range returnRequest.Items {
+			item := &returnRequest.Items[i]
+
+			var orderItem *OrderItem
+			for j := range order.Items {
+				if order.Items[j].ID == item.OrderItemID {
+					orderItem = &order.Items[j]
+				}
+			}
+			if orderItem == nil {
+				return fmt.Errorf("order item with ID %d: %w", item.OrderItemID, gorm.ErrRecordNotFound)
+			}
+
+			quantity := item.Quantity
+			for _, r := range returned {
+				if r.OrderItemID == item.OrderItemID {
+					quantity += r.Quantity
+				}
+			}
+			for _, other := range returnRequest.Items[:i] {
+				if other.OrderItemID == item.OrderItemID {
+					quantity += other.Quantity
+				}
+			}
+			if quantity > orderItem.Quantity {
+				return fmt.Errorf("order item with ID %d: %w", item.OrderItemID, ErrReturnQuantity)
+			}
+
+			// Shares are rounded on the quantity returned so far, so returning
+			// every unit adds up to exactly what was paid.
+			item.ProductID = orderItem.ProductID
+			item.Amount = returnShare(orderItem.Gross, quantity, orderItem.Quantity) - returnShare(orderItem.Gross, quantity-item.Quantity, orderItem.Quantity)
+			commission := (orderItem.Amount - orderItem.Discount).Percent(orderItem.CommissionRate)
+			item.Commission = returnShare(commission, quantity, orderItem.Quantity) - returnShare(commission, quantity-item.Quantity, orderItem.Quantity)
+			returnRequest.Amount += item.Amount
+		}
+
+		if err := tx.Create(returnRequest).Error; err != nil {
+			return err
+		}
+		return EnqueueJob(tx, JobReturnStatus, ReturnStatusPayload{ReturnID: returnRequest.ID, Status: ReturnRequested}, returnRequest.CreatedAt)
+	})
+}
+
+func returnShare(amount Money, quantity, total int) Money {
+	return Money(float64(amount)*float64(quantity)/float64(total) + 0.5)
+}
+
+// lockReturnRequest loads a return for update and checks it is in one of the
+// given statuses.
+func lockReturnRequest(tx *gorm.DB, id uint, statuses ...ReturnStatus) (*ReturnRequest, error) {
+	var returnRequest ReturnRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&returnRequest, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+
+	for _, status := range statuses {
+		if returnRequest.Status == status {
+			return &returnRequest, nil
+		}
+	}
+	return nil, ErrReturnProcessed
+}
+
+// ReviewReturnRequest records the seller's decision on a requested return.
+func ReviewReturnRequest(id uint, accept bool, note string, reviewedAt time.Time) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockReturnRequest(tx, id, ReturnRequested); err != nil {
+			return err
+		}
+
+		status := ReturnRejected
+		if accept {
+			status = ReturnAccepted
+		}
+		if err := tx.Model(&ReturnRequest{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"status":      status,
+			"note":        note,
+			"reviewed_at": reviewedAt,
+		}).Error; err != nil {
+			return err
+		}
+		return EnqueueJob(tx, JobReturnStatus, ReturnStatusPayload{ReturnID: id, Status: status}, reviewedAt)
+	})
+}
+
+// ShipReturn records the parcel the customer sends back. It is tracked like
+// any other shipment of the order.
+func ShipReturn(id uint, shipment *Shipment, shippedAt time.Time) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		returnRequest, err := lockReturnRequest(tx, id, ReturnAccepted)
+		if err != nil {
+			return err
+		}
+
+		shipment.OrderID = returnRequest.OrderID
+		shipment.ReturnRequestID = &returnRequest.ID
+		shipment.Status = ShipmentShipped
+		shipment.LastEventAt = shippedAt
+		shipment.Events = []ShipmentEvent{{
+			Status:      ShipmentShipped,
+			Description: "Return handed to " + shipment.Courier,
+			OccurredAt:  shippedAt,
+		}}
+		if err := tx.Create(shipment).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&ReturnRequest{}).Where("id = ?", id).Update("status", ReturnShipped).Error; err != nil {
+			return err
+		}
+		return EnqueueJob(tx, JobReturnStatus, ReturnStatusPayload{ReturnID: id, Status: ReturnShipped}, shippedAt)
+	})
+}
+
+// ReceiveReturn puts the returned items back in stock and fixes the amount
+// to refund. A return that was already received is left as it is, so the
+// refund can be retried after the payment provider failed.
+func ReceiveReturn(id uint, refundAmount Money, receivedAt time.Time) (*ReturnRequest, error) {
+	var received *ReturnRequest
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		returnRequest, err := lockReturnRequest(tx, id, ReturnAccepted, ReturnShipped, ReturnReceived)
+		if err != nil {
+			return err
+		}
+		received = returnRequest
+		if returnRequest.Status == ReturnReceived {
+			return nil
+		}
+
+		items := make([]OrderItem, len(returnRequest.Items))
+		for i, item := range returnRequest.Items {
+			items[i] = OrderItem{ProductID: item.ProductID, Quantity: item.Quantity}
+		}
+		if err := restockItems(tx, items, receivedAt); err != nil {
+			return err
+		}
+
+		if refundAmount <= 0 || refundAmount > returnRequest.Amount {
+			refundAmount = returnRequest.Amount
+		}
+		returnRequest.Status = ReturnReceived
+		returnRequest.RefundAmount = refundAmount
+		returnRequest.ReceivedAt = &receivedAt
+		return tx.Model(&ReturnRequest{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"status":        ReturnReceived,
+			"refund_amount": refundAmount,
+			"received_at":   receivedAt,
+		}).Error
+	})
+	return received, err
+}
+
+// CompleteRefund records a refund the payment provider made for a received
+// return. The seller is debited the refund and credited back the commission
+// on it, scaled down for partial refunds.
+func CompleteRefund(id uint, refundRef string, refundedAt time.Time) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		returnRequest, err := lockReturnRequest(tx, id, ReturnReceived)
+		if err != nil {
+			return err
+		}
+
+		var commission Money
+		for _, item := range returnRequest.Items {
+			commission += item.Commission
+		}
+		if returnRequest.Amount > 0 {
+			commission = Money(float64(commission)*float64(returnRequest.RefundAmount)/float64(returnRequest.Amount) + 0.5)
+		}
+		if err := recordRefund(tx, returnRequest.SellerID, returnRequest.RefundAmount, commission, returnRequest.Currency, returnRequest.Reference(), refundedAt); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&ReturnRequest{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"status":      ReturnRefunded,
+			"refund_ref":  refundRef,
+			"refunded_at": refundedAt,
+		}).Error; err != nil {
+			return err
+		}
+		return EnqueueJob(tx, JobReturnStatus, ReturnStatusPayload{ReturnID: id, Status: ReturnRefunded}, refundedAt)
+	})
+}
@@ -2,40 +2,96 @@ package models
 
 import (
 	"gofiber-marketplace/src/configs"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type Seller struct {
 	gorm.Model
-	UserID      uint      `json:"user_id" validate:"required"`
-	User        User      `gorm:"foreignKey:UserID" validate:"-"`
-	Name        string    `json:"name" validate:"required,max=50"`
-	Image       string    `json:"image" validate:"required"`
-	Phone       string    `json:"phone" validate:"required,numeric,max=15"`
-	Description string    `json:"description" validate:"required"`
-	Products    []Product `json:"products"`
+	UserID           uint       `json:"user_id" validate:"required"`
+	User             User       `gorm:"foreignKey:UserID" validate:"-"`
+	Name             string     `json:"name" validate:"required,max=50"`
+	Image            string     `json:"image" validate:"required"`
+	Phone            string     `json:"phone" validate:"required,numeric,max=15"`
+	Description      string     `json:"description" validate:"required"`
+	Handle           string     `json:"handle" gorm:"uniqueIndex:idx_sellers_handle,where:handle <> ''"`
+	Banner           string     `json:"banner"`
+	Location         string     `json:"location"`
+	OnVacation       bool       `json:"on_vacation" gorm:"default:false"`
+	VacationUntil    *time.Time `json:"vacation_until"`
+	VacationNote     string     `json:"vacation_note"`
+	PricesIncludeTax bool       `json:"prices_include_tax" gorm:"default:false"`
+	Currency         string     `json:"currency" gorm:"type:varchar(3);default:IDR"`
+	Products         []Product  `json:"products"`
+	ProductCount     int64      `json:"product_count" gorm:"->;-:migration" validate:"-"`
+	AverageRating    float64    `json:"average_rating" gorm:"->;-:migration" validate:"-"`
 }
 
-func SelectAllSellers(keyword, sort string) []*Seller {
+const sellerProductCount = "(SELECT COUNT(*) FROM products WHERE products.seller_id = sellers.id AND products.deleted_at IS NULL AND products.status = 'active') AS product_count"
+const sellerAverageRating = "(SELECT COALESCE(AVG(rating), 0) FROM products WHERE products.seller_id = sellers.id AND products.rating > 0 AND products.deleted_at IS NULL AND products.status = 'active') AS average_rating"
+
+func SelectAllSellers(keyword, sort string, limit, offset int) []*Seller {
 	var sellers []*Seller
 	keyword = "%" + keyword + "%"
-	configs.DB.Preload("User").Preload("Products").Order(sort).Where("name ILIKE ?", keyword).Find(&sellers)
+	configs.DB.Preload("User").Select("sellers.*, "+sellerProductCount).Order(sort).Limit(limit).Offset(offset).Where("name ILIKE ?", keyword).Find(&sellers)
 	return sellers
 }
 
+func CountSellers(keyword string) int64 {
+	var result int64
+	keyword = "%" + keyword + "%"
+	configs.DB.Table("sellers").Where("deleted_at IS NULL AND name ILIKE ?", keyword).Count(&result)
+	return result
+}
+
 func SelectSellerById(id int) *Seller {
 	var seller Seller
-	configs.DB.Preload("User").Preload("Products").First(&seller, "id = ?", id)
+	configs.DB.Preload("User").Select("sellers.*, "+sellerProductCount).First(&seller, "id = ?", id)
 	return &seller
 }
 
 func SelectSellerByUserId(id int) *Seller {
 	var seller Seller
-	configs.DB.Preload("User").Preload("Products").First(&seller, "user_id = ?", id)
+	configs.DB.Preload("User").Select("sellers.*, "+sellerProductCount).First(&seller, "user_id = ?", id)
+	return &seller
+}
+
+// SellerHasListings reports whether the seller ever listed a product or has
+// ledger entries. Both are in the seller's currency, so it cannot change after
+// that. Deleted products count too, since their sales stay on the ledger.
+func SellerHasListings(sellerId int) bool {
+	var products, entries int64
+	configs.DB.Table("products").Where("seller_id = ?", sellerId).Count(&products)
+	configs.DB.Table("ledger_entries").Where("seller_id = ?", sellerId).Count(&entries)
+	return products > 0 || entries > 0
+}
+
+func SelectSellerByHandle(handle string) *Seller {
+	var seller Seller
+	configs.DB.Select("sellers.*, "+sellerProductCount+", "+sellerAverageRating).First(&seller, "handle = ?", handle)
 	return &seller
 }
 
+// HandleTaken also counts deleted sellers, since the unique index on handle
+// still covers their rows.
+func HandleTaken(handle string) bool {
+	var result int64
+	configs.DB.Unscoped().Model(&Seller{}).Where("handle = ?", handle).Count(&result)
+	return result > 0
+}
+
+func SelectSellersWithoutHandle() []*Seller {
+	var sellers []*Seller
+	configs.DB.Unscoped().Where("handle = '' OR handle IS NULL").Find(&sellers)
+	return sellers
+}
+
+func UpdateSellerHandle(id int, handle string) error {
+	result := configs.DB.Unscoped().Model(&Seller{}).Where("id = ?", id).Update("handle", handle)
+	return result.Error
+}
+
 func CreateSeller(seller *Seller) error {
 	result := configs.DB.Create(&seller)
 	return result.Error
@@ -46,6 +102,33 @@ func UpdateSeller(id int, updatedSeller *Seller) error {
 	return result.Error
 }
 
+func UpdateSellerVacation(id int, onVacation bool, until *time.Time, note string) error {
+	result := configs.DB.Model(&Seller{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"on_vacation":    onVacation,
+		"vacation_until": until,
+		"vacation_note":  note,
+	})
+	return result.Error
+}
+
+func UpdateSellerTaxMode(id int, pricesIncludeTax bool) error {
+	result := configs.DB.Model(&Seller{}).Where("id = ?", id).Update("prices_include_tax", pricesIncludeTax)
+	return result.Error
+}
+
+// EndExpiredVacations reopens every store whose return date has passed and
+// reports how many were reopened.
+func EndExpiredVacations(now time.Time) (int64, error) {
+	result := configs.DB.Model(&Seller{}).
+		Where("on_vacation = ? AND vacation_until IS NOT NULL AND vacation_until <= ?", true, now).
+		Updates(map[string]interface{}{
+			"on_vacation":    false,
+			"vacation_until": nil,
+			"vacation_note":  "",
+		})
+	return result.RowsAffected, result.Error
+}
+
 func DeleteSeller(id int) error {
 	result := configs.DB.Delete(&Seller{}, "id = ?", id)
 	return result.Error
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProductView counts the detail page views of a product per UTC day, which is
+// what conversion is measured against.
+type ProductView struct {
+	ProductID uint      `json:"product_id" gorm:"primaryKey;autoIncrement:false"`
+	Day       time.Time `json:"day" gorm:"type:date;primaryKey"`
+	Views     int64     `json:"views"`
+}
+
+type SalesPeriod struct {
+	Period  time.Time `json:"period"`
+	Revenue Money     `json:"revenue"`
+	Orders  int64     `json:"orders"`
+	Units   int64     `json:"units"`
+	Views   int64     `json:"views"`
+}
+
+type ProductSales struct {
+	ProductID uint   `json:"product_id"`
+	Name      string `json:"name"`
+	Revenue   Money  `json:"revenue"`
+	Orders    int64  `json:"orders"`
+	Units     int64  `json:"units"`
+	Views     int64  `json:"views"`
+}
+
+func RecordProductView(productId uint, at time.Time) error {
+	day := at.UTC().Truncate(24 * time.Hour)
+	result := configs.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("product_views.views + 1")}),
+	}).Create(&ProductView{ProductID: productId, Day: day, Views: 1})
+	return result.Error
+}
+
+// Revenue is what the seller sold for after discounts and before tax and
+// shipping. Orders count from the moment they were paid; periods are UTC
+// and the range runs from the start of from to the start of to.
+const salesSeriesQuery = `
+WITH periods AS (
+	SELECT generate_series(date_trunc(@granularity, @from_day::timestamp), @to_day::timestamp - interval '1 day', ('1 ' || @granularity)::interval) AS period
+), sales AS (
+	SELECT date_trunc(@granularity, o.paid_at AT TIME ZONE 'UTC') AS period,
+		COUNT(DISTINCT o.id) AS orders, SUM(i.quantity) AS units, SUM(i.amount - i.discount) AS revenue
+	FROM orders o
+	JOIN order_items i ON i.order_id = o.id AND i.deleted_at IS NULL
+	WHERE o.deleted_at IS NULL AND o.seller_id = @seller_id AND o.paid_at >= @from AND o.paid_at < @to
+	GROUP BY 1
+), views AS (
+	SELECT date_trunc(@granularity, v.day::timestamp) AS period, SUM(v.views) AS views
+	FROM product_views v
+	JOIN products p ON p.id = v.product_id
+	WHERE p.seller_id = @seller_id AND v.day >= @from_day::date AND v.day < @to_day::date
+	GROUP BY 1
+)
+SELECT periods.period, COALESCE(sales.revenue, 0) AS revenue, COALESCE(sales.orders, 0) AS orders,
+	COALESCE(sales.units, 0) AS units, COALESCE(views.views, 0) AS views
+FROM periods
+LEFT JOIN sales ON sales.period = periods.period
+LEFT JOIN views ON views.period = periods.period
+ORDER BY periods.period`
+
+const topProductsQuery = `
+SELECT i.product_id, MAX(i.name) AS name, SUM(i.amount - i.discount) AS revenue,
+	COUNT(DISTINCT o.id) AS orders, SUM(i.quantity) AS units,
+	COALESCE((SELECT SUM(v.views) FROM product_views v WHERE v.product_id = i.product_id AND v.day >= @from_day::date AND v.day < @to_day::date), 0) AS views
+FROM orders o
+JOIN order_items i ON i.order_id = o.id AND i.deleted_at IS NULL
+WHERE o.deleted_at IS NULL AND o.seller_id = @seller_id AND o.paid_at >= @from AND o.paid_at < @to
+GROUP BY i.product_id
+ORDER BY revenue DESC, units DESC
+LIMIT @limit`
+
+func analyticsArgs(sellerId int, from, to time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"seller_id": sellerId,
+		"from":      from,
+		"to":        to,
+		"from_day":  from.Format("2006-01-02"),
+		"to_day":    to.Format("2006-01-02"),
+	}
+}
+
+// SelectSellerSalesSeries returns one row per period between from and to,
+// including periods without sales. granularity is day, week or month.
+func SelectSellerSalesSeries(sellerId int, granularity string, from, to time.Time) []*SalesPeriod {
+	var periods []*SalesPeriod
+	args := analyticsArgs(sellerId, from, to)
+	args["granularity"] = granularity
+	configs.DB.Raw(salesSeriesQuery, args).Scan(&periods)
+	return periods
+}
+
+func SelectSellerTopProducts(sellerId int, from, to time.Time, limit int) []*ProductSales {
+	var products []*ProductSales
+	args := analyticsArgs(sellerId, from, to)
+	args["limit"] = limit
+	configs.DB.Raw(topProductsQuery, args).Scan(&products)
+	return products
+}
@@ -0,0 +1,195 @@
+package models
+
+import (
+	"errors"
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShipmentStatus string
+
+const (
+	ShipmentShipped        ShipmentStatus = "shipped"
+	ShipmentInTransit      ShipmentStatus = "in_transit"
+	ShipmentOutForDelivery ShipmentStatus = "out_for_delivery"
+	ShipmentDelivered      ShipmentStatus = "delivered"
+	ShipmentFailed         ShipmentStatus = "failed"
+)
+
+// Shipment is a parcel handed to a courier, either the order on its way to
+// the customer or, with ReturnRequestID set, items on their way back. Its
+// status follows the newest event by OccurredAt, so tracking updates that
+// arrive out of order do not move it backwards.
+type Shipment struct {
+	gorm.Model
+	OrderID         uint            `json:"order_id" gorm:"index" validate:"required"`
+	ReturnRequestID *uint           `json:"return_request_id" gorm:"index"`
+	Courier         string          `json:"courier" validate:"required,max=50"`
+	TrackingNumber  string          `json:"tracking_number" gorm:"index" validate:"required,max=50"`
+	Status          ShipmentStatus  `gorm:"type:varchar(20);default:shipped" json:"status"`
+	LastEventAt     time.Time       `json:"last_event_at"`
+	DeliveredAt     *time.Time      `json:"delivered_at"`
+	Events          []ShipmentEvent `json:"events"`
+}
+
+// ShipmentEvent is one step of a shipment. ExternalID is the courier's id
+// for the event, which makes repeated webhook pushes idempotent.
+type ShipmentEvent struct {
+	gorm.Model
+	ShipmentID  uint           `json:"shipment_id" gorm:"index;uniqueIndex:idx_shipment_events_external,where:external_id <> ''" validate:"required"`
+	Status      ShipmentStatus `gorm:"type:varchar(20)" json:"status" validate:"required,oneof=shipped in_transit out_for_delivery delivered failed"`
+	Description string         `json:"description"`
+	Location    string         `json:"location"`
+	ExternalID  string         `json:"external_id" gorm:"uniqueIndex:idx_shipment_events_external,where:external_id <> ''"`
+	OccurredAt  time.Time      `json:"occurred_at" validate:"required"`
+}
+
+var ErrOrderStatus = errors.New("order status does not allow this change")
+
+func SelectShipmentsByOrderId(orderId int) []*Shipment {
+	var shipments []*Shipment
+	configs.DB.Preload("Events", func(db *gorm.DB) *gorm.DB {
+		return db.Order("occurred_at ASC")
+	}).Order("created_at ASC").Where("order_id = ?", orderId).Find(&shipments)
+	return shipments
+}
+
+func SelectShipmentByReturnRequestId(returnRequestId int) *Shipment {
+	var shipment Shipment
+	configs.DB.Preload("Events", func(db *gorm.DB) *gorm.DB {
+		return db.Order("occurred_at ASC")
+	}).First(&shipment, "return_request_id = ?", returnRequestId)
+	return &shipment
+}
+
+func SelectShipmentByTrackingNumber(courier, trackingNumber string) *Shipment {
+	var shipment Shipment
+	configs.DB.Order("created_at DESC").First(&shipment, "courier = ? AND tracking_number = ?", courier, trackingNumber)
+	return &shipment
+}
+
+// SelectOrdersToComplete returns delivered orders whose delivery is older
+// than the given time and that have no return in progress.
+func SelectOrdersToComplete(deliveredBefore time.Time, limit int) []*Order {
+	var orders []*Order
+	configs.DB.Order("delivered_at ASC").Limit(limit).
+		Where("status = ? AND delivered_at < ?", OrderDelivered, deliveredBefore).
+		Where("NOT EXISTS (SELECT 1 FROM return_requests WHERE return_requests.order_id = orders.id AND return_requests.deleted_at IS NULL AND return_requests.status IN ?)", openReturnStatuses).
+		Find(&orders)
+	return orders
+}
+
+// setOrderStatus moves a locked order from one of the allowed statuses to
+// status, stamping the time column that goes with it, and queues the status
+// notification.
+func setOrderStatus(tx *gorm.DB, orderId uint, from []OrderStatus, status OrderStatus, column string, at time.Time) error {
+	var order Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", orderId).Error; err != nil {
+		return err
+	}
+
+	allowed := false
+	for _, s := range from {
+		allowed = allowed || order.Status == s
+	}
+	if !allowed {
+		return ErrOrderStatus
+	}
+
+	if err := tx.Model(&Order{}).Where("id = ?", orderId).Updates(map[string]interface{}{
+		"status": status,
+		column:   at,
+	}).Error; err != nil {
+		return err
+	}
+	return EnqueueJob(tx, JobOrderStatus, OrderStatusPayload{OrderID: orderId, Status: status}, at)
+}
+
+// ShipOrder records the parcel of a paid order and marks the order shipped.
+func ShipOrder(shipment *Shipment, shippedAt time.Time) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := setOrderStatus(tx, shipment.OrderID, []OrderStatus{OrderPaid}, OrderShipped, "shipped_at", shippedAt); err != nil {
+			return err
+		}
+
+		shipment.Status = ShipmentShipped
+		shipment.LastEventAt = shippedAt
+		shipment.Events = []ShipmentEvent{{
+			Status:      ShipmentShipped,
+			Description: "Parcel handed to " + shipment.Courier,
+			OccurredAt:  shippedAt,
+		}}
+		return tx.Create(shipment).Error
+	})
+}
+
+// RecordShipmentEvent adds a tracking event to the shipment. An event already
+// recorded under the same ExternalID is ignored. The first delivered event of
+// an order's shipment marks the order delivered; returns are received by the
+// seller instead.
+func RecordShipmentEvent(shipmentId uint, event *ShipmentEvent) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var shipment Shipment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shipment, "id = ?", shipmentId).Error; err != nil {
+			return err
+		}
+
+		if event.ExternalID != "" {
+			var existing int64
+			if err := tx.Model(&ShipmentEvent{}).Where("shipment_id = ? AND external_id = ?", shipmentId, event.ExternalID).Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				return nil
+			}
+		}
+
+		event.ShipmentID = shipmentId
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if !event.OccurredAt.Before(shipment.LastEventAt) {
+			updates["status"] = event.Status
+			updates["last_event_at"] = event.OccurredAt
+		}
+		if event.Status == ShipmentDelivered && shipment.DeliveredAt == nil {
+			updates["delivered_at"] = event.OccurredAt
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&Shipment{}).Where("id = ?", shipmentId).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if event.Status != ShipmentDelivered || shipment.DeliveredAt != nil || shipment.ReturnRequestID != nil {
+			return nil
+		}
+		err := setOrderStatus(tx, shipment.OrderID, []OrderStatus{OrderShipped}, OrderDelivered, "delivered_at", event.OccurredAt)
+		if errors.Is(err, ErrOrderStatus) {
+			return nil
+		}
+		return err
+	})
+}
+
+// CompleteOrder closes a delivered order, either when the customer confirms
+// it or when the confirmation period runs out. Orders with a return in
+// progress stay open until the return is settled.
+func CompleteOrder(orderId uint, completedAt time.Time) error {
+	return configs.DB.Transaction(func(tx *gorm.DB) error {
+		var open int64
+		if err := tx.Model(&ReturnRequest{}).Where("order_id = ? AND status IN ?", orderId, openReturnStatuses).Count(&open).Error; err != nil {
+			return err
+		}
+		if open > 0 {
+			return ErrReturnOpen
+		}
+
+		return setOrderStatus(tx, orderId, []OrderStatus{OrderDelivered}, OrderCompleted, "completed_at", completedAt)
+	})
+}
@@ -10,7 +10,7 @@ type User struct {
 	gorm.Model
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8,max=20"`
-	Role     string `json:"role" validate:"oneof=seller customer"`
+	Role     string `json:"role" validate:"oneof=seller customer admin"`
 }
 
 func SelectAllUsers() []*User {
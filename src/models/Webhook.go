@@ -0,0 +1,143 @@
+package models
+
+import (
+	"gofiber-marketplace/src/configs"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	WebhookProductUpdated = "product.updated"
+	WebhookStockLow       = "stock.low"
+	WebhookPing           = "ping"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending"
+	WebhookSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookFailed    WebhookDeliveryStatus = "failed"
+)
+
+type WebhookEndpoint struct {
+	gorm.Model
+	SellerID uint   `json:"seller_id" gorm:"index" validate:"required"`
+	URL      string `json:"url" validate:"required,url"`
+	Secret   string `json:"-"`
+	Events   string `json:"events" validate:"required"`
+	Active   bool   `json:"active" gorm:"default:true"`
+}
+
+type WebhookDelivery struct {
+	gorm.Model
+	EndpointID    uint                  `json:"endpoint_id" gorm:"index" validate:"required"`
+	Endpoint      WebhookEndpoint       `gorm:"foreignKey:EndpointID" validate:"-"`
+	Event         string                `gorm:"type:varchar(50)" json:"event" validate:"required"`
+	Payload       string                `json:"payload" validate:"required"`
+	Status        WebhookDeliveryStatus `gorm:"type:varchar(20);default:pending;index" json:"status"`
+	Attempts      int                   `json:"attempts"`
+	ResponseCode  int                   `json:"response_code"`
+	Error         string                `json:"error"`
+	NextAttemptAt *time.Time            `json:"next_attempt_at" gorm:"index"`
+	DeliveredAt   *time.Time            `json:"delivered_at"`
+}
+
+func SelectWebhookEndpointsBySellerId(sellerId int) []*WebhookEndpoint {
+	var endpoints []*WebhookEndpoint
+	configs.DB.Order("created_at DESC").Where("seller_id = ?", sellerId).Find(&endpoints)
+	return endpoints
+}
+
+func SelectWebhookEndpointById(id int) *WebhookEndpoint {
+	var endpoint WebhookEndpoint
+	configs.DB.First(&endpoint, "id = ?", id)
+	return &endpoint
+}
+
+func SelectWebhookEndpointsForEvent(sellerId uint, event string) []*WebhookEndpoint {
+	var endpoints []*WebhookEndpoint
+	configs.DB.Where("seller_id = ? AND active = ? AND ? = ANY(string_to_array(events, ','))", sellerId, true, event).Find(&endpoints)
+	return endpoints
+}
+
+func CreateWebhookEndpoint(endpoint *WebhookEndpoint) error {
+	result := configs.DB.Create(&endpoint)
+	return result.Error
+}
+
+func UpdateWebhookEndpoint(id int, url, events string, active bool) error {
+	result := configs.DB.Model(&WebhookEndpoint{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"url":    url,
+		"events": events,
+		"active": active,
+	})
+	return result.Error
+}
+
+func DeleteWebhookEndpoint(id int) error {
+	result := configs.DB.Delete(&WebhookEndpoint{}, "id = ?", id)
+	return result.Error
+}
+
+func SelectWebhookDeliveriesByEndpointId(endpointId, limit, offset int) []*WebhookDelivery {
+	var deliveries []*WebhookDelivery
+	configs.DB.Order("created_at DESC").Limit(limit).Offset(offset).Where("endpoint_id = ?", endpointId).Find(&deliveries)
+	return deliveries
+}
+
+func CountWebhookDeliveriesByEndpointId(endpointId int) int64 {
+	var result int64
+	configs.DB.Table("webhook_deliveries").Where("deleted_at IS NULL AND endpoint_id = ?", endpointId).Count(&result)
+	return result
+}
+
+func SelectWebhookDeliveryById(id int) *WebhookDelivery {
+	var delivery WebhookDelivery
+	configs.DB.Preload("Endpoint").First(&delivery, "id = ?", id)
+	return &delivery
+}
+
+// ClaimDueWebhookDeliveries locks due deliveries with SKIP LOCKED and pushes
+// their next attempt out by lease, so other workers and processes skip them
+// while this one sends. A worker that dies mid-send leaves them to be retried
+// once the lease runs out.
+func ClaimDueWebhookDeliveries(now time.Time, limit int, lease time.Duration) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+	err := configs.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Order("next_attempt_at ASC").Limit(limit).
+			Where("status = ? AND next_attempt_at <= ?", WebhookPending, now).
+			Find(&deliveries).Error; err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+		}
+		if err := tx.Model(&WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error; err != nil {
+			return err
+		}
+
+		return tx.Preload("Endpoint").Where("id IN ?", ids).Order("next_attempt_at ASC").Find(&deliveries).Error
+	})
+	return deliveries, err
+}
+
+func CreateWebhookDelivery(delivery *WebhookDelivery) error {
+	result := configs.DB.Create(&delivery)
+	return result.Error
+}
+
+func CreateWebhookDeliveries(deliveries []*WebhookDelivery) error {
+	result := configs.DB.Create(&deliveries)
+	return result.Error
+}
+
+func SaveWebhookDelivery(delivery *WebhookDelivery) error {
+	result := configs.DB.Omit("Endpoint").Save(&delivery)
+	return result.Error
+}
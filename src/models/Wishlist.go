@@ -0,0 +1,49 @@
+package models
+
+import (
+	"gofiber-marketplace/src/configs"
+
+	"gorm.io/gorm"
+)
+
+type Wishlist struct {
+	gorm.Model
+	CustomerID uint     `json:"customer_id" gorm:"uniqueIndex:idx_wishlist_customer_product" validate:"required"`
+	Customer   Customer `gorm:"foreignKey:CustomerID" validate:"-"`
+	ProductID  uint     `json:"product_id" gorm:"uniqueIndex:idx_wishlist_customer_product" validate:"required"`
+	Product    Product  `gorm:"foreignKey:ProductID" validate:"-"`
+}
+
+func SelectWishlistsByCustomerId(customerId, limit, offset int) []*Wishlist {
+	var wishlists []*Wishlist
+	configs.DB.Preload("Product").Order("created_at DESC").Limit(limit).Offset(offset).Where("customer_id = ?", customerId).Find(&wishlists)
+	return wishlists
+}
+
+func CountWishlistsByCustomerId(customerId int) int64 {
+	var result int64
+	configs.DB.Table("wishlists").Where("deleted_at IS NULL AND customer_id = ?", customerId).Count(&result)
+	return result
+}
+
+func CountWishlistsByProductId(productId int) int64 {
+	var result int64
+	configs.DB.Table("wishlists").Where("deleted_at IS NULL AND product_id = ?", productId).Count(&result)
+	return result
+}
+
+func SelectWishlist(customerId, productId int) *Wishlist {
+	var wishlist Wishlist
+	configs.DB.First(&wishlist, "customer_id = ? AND product_id = ?", customerId, productId)
+	return &wishlist
+}
+
+func CreateWishlist(wishlist *Wishlist) error {
+	result := configs.DB.Create(&wishlist)
+	return result.Error
+}
+
+func DeleteWishlist(customerId, productId int) error {
+	result := configs.DB.Unscoped().Delete(&Wishlist{}, "customer_id = ? AND product_id = ?", customerId, productId)
+	return result.Error
+}
@@ -4,20 +4,27 @@ import (
 	"gofiber-marketplace/src/controllers"
 	"gofiber-marketplace/src/middlewares"
 
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 )
 
 func Router(app *fiber.App) {
 	// Product Routes
 	app.Get("/products", controllers.GetAllProduct)
-	app.Get("/product/:id", controllers.GetDetailProduct)
+	app.Post("/products/import", middlewares.JWTMiddleware(), controllers.ImportProducts)
+	app.Get("/products/import/:id", middlewares.JWTMiddleware(), controllers.GetProductImport)
+	app.Get("/products/export", middlewares.JWTMiddleware(), controllers.ExportProducts)
+	app.Get("/product/:id", middlewares.OptionalJWTMiddleware(), controllers.GetDetailProduct)
+	app.Get("/product/:id/price-history", controllers.GetProductPriceHistory)
 	app.Post("/product", middlewares.JWTMiddleware(), controllers.CreateProduct)
 	app.Put("/product/:id", middlewares.JWTMiddleware(), controllers.UpdateProduct)
 	app.Delete("/product/:id", middlewares.JWTMiddleware(), controllers.DeleteProduct)
+	app.Put("/product/:id/status", middlewares.JWTMiddleware(), controllers.UpdateProductListingStatus)
 
 	// Category Routes
 	app.Get("/categories", controllers.GetAllCategories)
 	app.Get("/category/:id", controllers.GetCategoryById)
+	app.Get("/category/:id/products", controllers.GetCategoryProducts)
 	app.Post("/category", middlewares.JWTMiddleware(), controllers.CreateCategory)
 	app.Put("/category/:id", middlewares.JWTMiddleware(), controllers.UpdateCategory)
 	app.Delete("/category/:id", middlewares.JWTMiddleware(), controllers.DeleteCategory)
@@ -25,10 +32,25 @@ func Router(app *fiber.App) {
 	// Seller Routes
 	app.Get("/sellers", middlewares.JWTMiddleware(), controllers.GetSellers)
 	app.Get("/sellers/:id", middlewares.JWTMiddleware(), controllers.GetDetailSeller)
+	app.Get("/sellers/:id/products", middlewares.JWTMiddleware(), controllers.GetSellerProducts)
 	app.Get("/seller/profile", middlewares.JWTMiddleware(), controllers.GetSellerProfile)
 	app.Put("/seller/profile", middlewares.JWTMiddleware(), controllers.UpdateSellerProfile)
 	app.Delete("/seller/profile", middlewares.JWTMiddleware(), controllers.DeleteSeller)
 	app.Put("/seller/profile/photo", middlewares.JWTMiddleware(), controllers.UpdateSellerProfilePhoto)
+	app.Put("/seller/profile/banner", middlewares.JWTMiddleware(), controllers.UpdateSellerBanner)
+	app.Put("/seller/vacation", middlewares.JWTMiddleware(), controllers.UpdateSellerVacation)
+	app.Put("/seller/tax", middlewares.JWTMiddleware(), controllers.UpdateSellerTaxMode)
+	app.Get("/seller/products", middlewares.JWTMiddleware(), controllers.GetSellerCatalog)
+	app.Get("/seller/balance", middlewares.JWTMiddleware(), controllers.GetSellerBalance)
+	app.Get("/seller/ledger", middlewares.JWTMiddleware(), controllers.GetSellerLedger)
+	app.Get("/seller/payouts", middlewares.JWTMiddleware(), controllers.GetSellerPayouts)
+	app.Post("/seller/payout", middlewares.JWTMiddleware(), controllers.CreatePayout)
+	app.Get("/seller/analytics", middlewares.JWTMiddleware(), controllers.GetSellerAnalytics)
+	app.Get("/seller/analytics/export", middlewares.JWTMiddleware(), controllers.ExportSellerAnalytics)
+
+	// Store Routes
+	app.Get("/store/:handle", controllers.GetStore)
+	app.Get("/store/:handle/products", controllers.GetStoreProducts)
 
 	// Customer Routes
 	app.Get("/customers", middlewares.JWTMiddleware(), controllers.GetCustomers)
@@ -36,11 +58,13 @@ func Router(app *fiber.App) {
 	app.Get("/customer/profile", middlewares.JWTMiddleware(), controllers.GetCustomerProfile)
 	app.Put("/customer/profile", middlewares.JWTMiddleware(), controllers.UpdateCustomerProfile)
 	app.Delete("/customer/profile", middlewares.JWTMiddleware(), controllers.DeleteCustomer)
+	app.Get("/customer/points", middlewares.JWTMiddleware(), controllers.GetCustomerPoints)
 
 	// User/Auth Routes
 	app.Post("/register", controllers.RegisterUser)
 	app.Post("/login", controllers.LoginUser)
 	app.Post("/refreshToken", controllers.CreateRefreshToken)
+	app.Post("/stream-ticket", middlewares.JWTMiddleware(), controllers.CreateStreamTicket)
 
 	// Address Routes
 	app.Get("/addresses", middlewares.JWTMiddleware(), controllers.GetAddresses)
@@ -48,6 +72,96 @@ func Router(app *fiber.App) {
 	app.Put("/address/:id", middlewares.JWTMiddleware(), controllers.UpdateAddress)
 	app.Delete("/address/:id", middlewares.JWTMiddleware(), controllers.DeleteAddress)
 
+	// Shipping Routes
+	app.Post("/shipping/quote", middlewares.JWTMiddleware(), controllers.QuoteShipping)
+	app.Post("/shipping/tracking", controllers.ReceiveTrackingUpdate)
+	app.Post("/cart/totals", middlewares.JWTMiddleware(), controllers.GetCartTotals)
+
+	// Order Routes
+	app.Post("/checkout", middlewares.JWTMiddleware(), controllers.Checkout)
+	app.Get("/orders", middlewares.JWTMiddleware(), controllers.GetOrders)
+	app.Get("/orders/:id/invoice.pdf", middlewares.JWTMiddleware(), controllers.GetOrderInvoice)
+	app.Get("/order/:id", middlewares.JWTMiddleware(), controllers.GetDetailOrder)
+	app.Get("/order/:id/timeline", middlewares.JWTMiddleware(), controllers.GetOrderTimeline)
+	app.Put("/order/:id/ship", middlewares.JWTMiddleware(), controllers.ShipOrder)
+	app.Put("/order/:id/complete", middlewares.JWTMiddleware(), controllers.CompleteOrder)
+
+	// Return Routes
+	app.Post("/order/:id/return", middlewares.JWTMiddleware(), controllers.CreateReturnRequest)
+	app.Get("/returns", middlewares.JWTMiddleware(), controllers.GetReturnRequests)
+	app.Get("/return/:id", middlewares.JWTMiddleware(), controllers.GetDetailReturnRequest)
+	app.Put("/return/:id/accept", middlewares.JWTMiddleware(), controllers.AcceptReturnRequest)
+	app.Put("/return/:id/reject", middlewares.JWTMiddleware(), controllers.RejectReturnRequest)
+	app.Put("/return/:id/ship", middlewares.JWTMiddleware(), controllers.ShipReturn)
+	app.Put("/return/:id/receive", middlewares.JWTMiddleware(), controllers.ReceiveReturn)
+
+	// Wishlist Routes
+	app.Get("/wishlist", middlewares.JWTMiddleware(), controllers.GetWishlists)
+	app.Post("/wishlist/:productId", middlewares.JWTMiddleware(), controllers.CreateWishlist)
+	app.Delete("/wishlist/:productId", middlewares.JWTMiddleware(), controllers.DeleteWishlist)
+
+	// Product Alert Routes
+	app.Get("/alerts", middlewares.JWTMiddleware(), controllers.GetProductAlerts)
+	app.Post("/alert", middlewares.JWTMiddleware(), controllers.CreateProductAlert)
+	app.Delete("/alert/:id", middlewares.JWTMiddleware(), controllers.DeleteProductAlert)
+
+	// Product Q&A Routes
+	app.Get("/product/:id/questions", controllers.GetProductQuestions)
+	app.Post("/product/:id/question", middlewares.JWTMiddleware(), controllers.CreateProductQuestion)
+	app.Post("/question/:id/answer", middlewares.JWTMiddleware(), controllers.CreateProductAnswer)
+	app.Post("/question/:id/upvote", middlewares.JWTMiddleware(), controllers.UpvoteProductQuestion)
+	app.Post("/answer/:id/upvote", middlewares.JWTMiddleware(), controllers.UpvoteProductAnswer)
+
+	// Product Review Routes
+	app.Get("/product/:id/reviews", controllers.GetProductReviews)
+	app.Post("/product/:id/review", middlewares.JWTMiddleware(), controllers.CreateProductReview)
+
+	// Chat Routes
+	app.Get("/conversations", middlewares.JWTMiddleware(), controllers.GetConversations)
+	app.Post("/conversation", middlewares.JWTMiddleware(), controllers.CreateConversation)
+	app.Get("/conversation/:id/messages", middlewares.JWTMiddleware(), controllers.GetConversationMessages)
+	app.Post("/conversation/:id/message", middlewares.JWTMiddleware(), controllers.CreateConversationMessage)
+	app.Put("/conversation/:id/read", middlewares.JWTMiddleware(), controllers.ReadConversation)
+	app.Get("/chat/ws", middlewares.WebSocketJWTMiddleware(), websocket.New(controllers.ChatSocket))
+
+	// Notification Routes
+	app.Get("/notifications", middlewares.JWTMiddleware(), controllers.GetNotifications)
+	app.Get("/notifications/stream", middlewares.StreamTicketMiddleware(), controllers.StreamNotifications)
+	app.Put("/notifications/read", middlewares.JWTMiddleware(), controllers.ReadAllNotifications)
+	app.Put("/notification/:id/read", middlewares.JWTMiddleware(), controllers.ReadNotification)
+
+	// Webhook Routes
+	app.Get("/seller/webhooks", middlewares.JWTMiddleware(), controllers.GetWebhookEndpoints)
+	app.Post("/seller/webhook", middlewares.JWTMiddleware(), controllers.CreateWebhookEndpoint)
+	app.Put("/seller/webhook/:id", middlewares.JWTMiddleware(), controllers.UpdateWebhookEndpoint)
+	app.Delete("/seller/webhook/:id", middlewares.JWTMiddleware(), controllers.DeleteWebhookEndpoint)
+	app.Post("/seller/webhook/:id/test", middlewares.JWTMiddleware(), controllers.TestWebhookEndpoint)
+	app.Get("/seller/webhook/:id/deliveries", middlewares.JWTMiddleware(), controllers.GetWebhookDeliveries)
+	app.Post("/seller/webhook/delivery/:id/redeliver", middlewares.JWTMiddleware(), controllers.RedeliverWebhook)
+
+	// Coupon Routes
+	app.Get("/coupons", middlewares.JWTMiddleware(), controllers.GetCoupons)
+	app.Post("/coupon", middlewares.JWTMiddleware(), controllers.CreateCoupon)
+	app.Put("/coupon/:id", middlewares.JWTMiddleware(), controllers.UpdateCoupon)
+	app.Delete("/coupon/:id", middlewares.JWTMiddleware(), controllers.DeleteCoupon)
+	app.Post("/coupon/validate", middlewares.JWTMiddleware(), controllers.ValidateCoupon)
+
+	// Promotion Routes
+	app.Get("/seller/promotions", middlewares.JWTMiddleware(), controllers.GetSellerPromotions)
+	app.Post("/promotion", middlewares.JWTMiddleware(), controllers.CreatePromotion)
+	app.Put("/promotion/:id", middlewares.JWTMiddleware(), controllers.UpdatePromotion)
+	app.Delete("/promotion/:id", middlewares.JWTMiddleware(), controllers.DeletePromotion)
+
+	// Admin Routes
+	app.Get("/admin/products/pending", middlewares.JWTMiddleware(), controllers.GetModerationQueue)
+	app.Put("/admin/product/:id/approve", middlewares.JWTMiddleware(), controllers.ApproveProduct)
+	app.Put("/admin/product/:id/reject", middlewares.JWTMiddleware(), controllers.RejectProduct)
+	app.Get("/admin/payouts", middlewares.JWTMiddleware(), controllers.GetPayouts)
+	app.Put("/admin/payout/:id/approve", middlewares.JWTMiddleware(), controllers.ApprovePayout)
+	app.Put("/admin/payout/:id/reject", middlewares.JWTMiddleware(), controllers.RejectPayout)
+	app.Get("/admin/jobs", middlewares.JWTMiddleware(), controllers.GetJobs)
+	app.Put("/admin/job/:id/retry", middlewares.JWTMiddleware(), controllers.RetryJob)
+
 	// Upload Routes
 	app.Post("/upload", controllers.UploadFile)
 	app.Post("/uploadServer", controllers.UploadFileServer)
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"gofiber-marketplace/src/models"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+)
+
+// chatWriteWait bounds how long a write may block on a slow or dead client
+// before the socket is given up on.
+const chatWriteWait = 10 * time.Second
+
+type chatClient struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+// ChatHub keeps the open chat sockets per user. A user may be connected from
+// several devices, so every event is written to all of their sockets. Those
+// sockets may sit on a different process than the sender, so events go
+// through Postgres LISTEN/NOTIFY (see Publish and Listen).
+type ChatHub struct {
+	mu      sync.RWMutex
+	clients map[uint]map[*websocket.Conn]*chatClient
+}
+
+func NewChatHub() *ChatHub {
+	return &ChatHub{clients: make(map[uint]map[*websocket.Conn]*chatClient)}
+}
+
+func (h *ChatHub) Register(userId uint, conn *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.clients[userId] == nil {
+		h.clients[userId] = make(map[*websocket.Conn]*chatClient)
+	}
+	h.clients[userId][conn] = &chatClient{conn: conn}
+}
+
+func (h *ChatHub) Unregister(userId uint, conn *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.clients[userId], conn)
+	if len(h.clients[userId]) == 0 {
+		delete(h.clients, userId)
+	}
+}
+
+func (h *ChatHub) IsOnline(userId uint) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.clients[userId]) > 0
+}
+
+// Send writes an event to this process's sockets of a user and reports how
+// many of them took it.
+func (h *ChatHub) Send(userId uint, event interface{}) int {
+	h.mu.RLock()
+	clients := make([]*chatClient, 0, len(h.clients[userId]))
+	for _, client := range h.clients[userId] {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+
+	sent := 0
+	for _, client := range clients {
+		if h.write(userId, client, event) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// chatEnvelope is what goes over models.ChatChannel. Messages travel by ID and
+// are loaded by each listener, since a NOTIFY payload is capped at 8000 bytes.
+type chatEnvelope struct {
+	UserIDs   []uint          `json:"user_ids"`
+	MessageID uint            `json:"message_id,omitempty"`
+	Event     json.RawMessage `json:"event,omitempty"`
+}
+
+// Publish sends an event to every open socket of the users, on whichever
+// process they are connected to.
+func (h *ChatHub) Publish(userIds []uint, event interface{}) {
+	data, err := json.Marshal(event)
+	if err == nil {
+		err = signalChat(chatEnvelope{UserIDs: userIds, Event: data})
+	}
+	if err != nil {
+		log.Printf("Failed to announce chat event, sending locally only: %v", err)
+		for _, userId := range userIds {
+			h.Send(userId, event)
+		}
+	}
+}
+
+// PublishMessage sends a new message like Publish. The message is marked
+// delivered once it reaches a socket of anyone but its sender.
+func (h *ChatHub) PublishMessage(userIds []uint, message *models.ChatMessage, event interface{}) {
+	if err := signalChat(chatEnvelope{UserIDs: userIds, MessageID: message.ID}); err != nil {
+		log.Printf("Failed to announce chat message %d, sending locally only: %v", message.ID, err)
+		h.deliverMessage(userIds, message, event)
+	}
+}
+
+func (h *ChatHub) deliverMessage(userIds []uint, message *models.ChatMessage, event interface{}) {
+	for _, userId := range userIds {
+		if h.Send(userId, event) > 0 && userId != message.SenderID {
+			if err := models.MarkChatMessageDelivered(message.ID, time.Now()); err != nil {
+				log.Printf("Failed to mark chat message %d delivered: %v", message.ID, err)
+			}
+		}
+	}
+}
+
+// Listen writes the chat events announced by every process to this process's
+// sockets until ctx is cancelled. messageEvent builds the event for a new
+// message.
+func (h *ChatHub) Listen(ctx context.Context, databaseURL string, messageEvent func(message *models.ChatMessage) interface{}) {
+	listenChannel(ctx, databaseURL, models.ChatChannel, func(payload string) {
+		var envelope chatEnvelope
+		if err := json.Unmarshal([]byte(payload), &envelope); err != nil {
+			return
+		}
+
+		online := false
+		for _, userId := range envelope.UserIDs {
+			online = online || h.IsOnline(userId)
+		}
+		if !online {
+			return
+		}
+
+		if envelope.MessageID == 0 {
+			for _, userId := range envelope.UserIDs {
+				h.Send(userId, envelope.Event)
+			}
+			return
+		}
+
+		if message := models.SelectChatMessageById(int(envelope.MessageID)); message.ID != 0 {
+			h.deliverMessage(envelope.UserIDs, message, messageEvent(message))
+		}
+	})
+}
+
+func signalChat(envelope chatEnvelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	return models.SignalChatEvent(string(payload))
+}
+
+// Reply writes an event to a single socket, e.g. an error for the frame it
+// just received.
+func (h *ChatHub) Reply(userId uint, conn *websocket.Conn, event interface{}) {
+	h.mu.RLock()
+	client := h.clients[userId][conn]
+	h.mu.RUnlock()
+
+	if client == nil {
+		return
+	}
+
+	h.write(userId, client, event)
+}
+
+// write sends an event to one socket. A socket that fails or times out is
+// dropped and closed, which also ends its read loop.
+func (h *ChatHub) write(userId uint, client *chatClient, event interface{}) bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	err := WriteSocketJSON(client.conn, event)
+	if err == nil {
+		return true
+	}
+
+	log.Printf("Failed to write chat event to user %d: %v", userId, err)
+	h.Unregister(userId, client.conn)
+	client.conn.Close()
+	return false
+}
+
+// WriteSocketJSON writes an event with a write deadline, so a client that
+// stops reading cannot block the sender forever.
+func WriteSocketJSON(conn *websocket.Conn, event interface{}) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(chatWriteWait)); err != nil {
+		return err
+	}
+	return conn.WriteJSON(event)
+}
+
+var DefaultChatHub = NewChatHub()
@@ -3,8 +3,10 @@ package services
 import (
 	"fmt"
 	"mime/multipart"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/cloudinary/cloudinary-go"
@@ -44,3 +46,18 @@ func UploadCloudinary(c *fiber.Ctx, file *multipart.FileHeader) (*uploader.Uploa
 
 	return uploadResult, nil
 }
+
+// IsUploadedImage reports whether the URL points at an image in this app's
+// Cloudinary account, i.e. one that went through the upload endpoint.
+func IsUploadedImage(rawURL string) bool {
+	config, err := url.Parse(os.Getenv("CLOUDINARY_URL"))
+	if err != nil || config.Host == "" {
+		return false
+	}
+
+	image, err := url.Parse(rawURL)
+	if err != nil || (image.Scheme != "https" && image.Scheme != "http") {
+		return false
+	}
+	return image.Host == "res.cloudinary.com" && strings.HasPrefix(image.Path, "/"+config.Host+"/image/upload/")
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ExchangeRateProvider interface {
+	// Rate returns how many units of to one unit of from is worth.
+	Rate(from, to string) (float64, error)
+}
+
+var ErrUnsupportedCurrency = errors.New("currency is not supported")
+
+// FileExchangeRateProvider reads rates from a JSON file such as
+// {"base": "IDR", "rates": {"USD": 0.0000613}} instead of a rates API. The
+// file is read again whenever it changes, so rates can be updated without a
+// restart.
+type FileExchangeRateProvider struct {
+	// Path falls back to EXCHANGE_RATES_FILE, then exchange_rates.json.
+	Path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	rates   map[string]float64
+}
+
+type exchangeRateFile struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+func (p *FileExchangeRateProvider) Rate(from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	rates, err := p.load()
+	if err != nil {
+		return 0, err
+	}
+
+	fromRate, fromOk := rates[from]
+	toRate, toOk := rates[to]
+	if !fromOk || !toOk {
+		return 0, ErrUnsupportedCurrency
+	}
+	return toRate / fromRate, nil
+}
+
+func (p *FileExchangeRateProvider) load() (map[string]float64, error) {
+	path := p.Path
+	if path == "" {
+		path = os.Getenv("EXCHANGE_RATES_FILE")
+	}
+	if path == "" {
+		path = "exchange_rates.json"
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if p.rates != nil && info.ModTime().Equal(p.modTime) {
+		return p.rates, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file exchangeRateFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+
+	rates := map[string]float64{strings.ToUpper(file.Base): 1}
+	for currency, rate := range file.Rates {
+		if rate > 0 {
+			rates[strings.ToUpper(currency)] = rate
+		}
+	}
+
+	p.rates = rates
+	p.modTime = info.ModTime()
+	return rates, nil
+}
+
+var DefaultExchangeRates ExchangeRateProvider = &FileExchangeRateProvider{}
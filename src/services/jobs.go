@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gofiber-marketplace/src/models"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// jobLockHeartbeat is how often a running job's lock is extended.
+	jobLockHeartbeat = time.Minute
+	// jobLockTimeout is how long a lock may go unextended before the job
+	// counts as abandoned. It spans several missed heartbeats.
+	jobLockTimeout = 5 * time.Minute
+)
+
+type JobHandler func(ctx context.Context, payload []byte) error
+
+type recurringJob struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+}
+
+// JobRunner works through the jobs table with a fixed pool of workers and
+// also runs recurring tasks. Every process schedules the recurring tasks, but
+// an advisory lock lets only one of them run a task at a time. While a job
+// runs its lock is extended, so only jobs of dead workers go stale and are
+// put back in the queue. Shutdown stops picking up new work and waits for
+// running jobs; their context is only cancelled if the shutdown deadline
+// passes.
+type JobRunner struct {
+	workers      int
+	pollInterval time.Duration
+	handlers     map[string]JobHandler
+	recurring    []recurringJob
+
+	stop   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewJobRunner(workers int, pollInterval time.Duration) *JobRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &JobRunner{
+		workers:      workers,
+		pollInterval: pollInterval,
+		handlers:     make(map[string]JobHandler),
+		stop:         make(chan struct{}),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+func (r *JobRunner) Handle(jobType string, handler JobHandler) {
+	r.handlers[jobType] = handler
+}
+
+func (r *JobRunner) Every(name string, interval time.Duration, run func(ctx context.Context) error) {
+	r.recurring = append(r.recurring, recurringJob{name: name, interval: interval, run: run})
+}
+
+func (r *JobRunner) Start() {
+	r.Every("jobs.release", jobLockHeartbeat, releaseStaleJobs)
+
+	for i := 0; i < r.workers; i++ {
+		r.wg.Add(1)
+		go r.work()
+	}
+
+	for _, job := range r.recurring {
+		r.wg.Add(1)
+		go r.schedule(job)
+	}
+}
+
+func (r *JobRunner) Shutdown(ctx context.Context) error {
+	close(r.stop)
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.cancel()
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		return ctx.Err()
+	}
+}
+
+func (r *JobRunner) work() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+
+		job, err := models.ClaimJob(time.Now())
+		if err != nil {
+			log.Printf("Failed to claim job: %v", err)
+		}
+		if job == nil {
+			select {
+			case <-r.stop:
+				return
+			case <-time.After(r.pollInterval):
+			}
+			continue
+		}
+
+		r.process(job)
+	}
+}
+
+func (r *JobRunner) process(job *models.Job) {
+	stopHeartbeat := make(chan struct{})
+	go heartbeat(job.ID, stopHeartbeat)
+
+	err := r.execute(job)
+	close(stopHeartbeat)
+	now := time.Now()
+
+	switch {
+	case err == nil:
+		err = models.CompleteJob(job.ID, now)
+	case job.Attempts >= job.MaxAttempts:
+		log.Printf("Job %d (%s) moved to dead letter after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+		err = models.BuryJob(job.ID, err.Error(), now)
+	default:
+		err = models.RetryJob(job.ID, err.Error(), now.Add(JobBackoff(job.Attempts)))
+	}
+
+	if err != nil {
+		log.Printf("Failed to record result of job %d: %v", job.ID, err)
+	}
+}
+
+func (r *JobRunner) execute(job *models.Job) (err error) {
+	handler, ok := r.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("no handler for job type %q", job.Type)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return handler(r.ctx, []byte(job.Payload))
+}
+
+func (r *JobRunner) schedule(job recurringJob) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			err := models.TryAdvisoryLock("recurring:"+job.name, func() error {
+				return job.run(r.ctx)
+			})
+			if err != nil {
+				log.Printf("Recurring job %s failed: %v", job.name, err)
+			}
+		}
+	}
+}
+
+func heartbeat(jobId uint, stop <-chan struct{}) {
+	ticker := time.NewTicker(jobLockHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if err := models.ExtendJobLock(jobId, now); err != nil {
+				log.Printf("Failed to extend lock of job %d: %v", jobId, err)
+			}
+		}
+	}
+}
+
+func releaseStaleJobs(ctx context.Context) error {
+	released, err := models.ReleaseStaleJobs(time.Now().Add(-jobLockTimeout))
+	if released > 0 {
+		log.Printf("Released %d stale jobs", released)
+	}
+	return err
+}
+
+// JobBackoff returns the wait before retrying a failed job: 10s, 20s, 40s...
+// capped at one hour.
+func JobBackoff(attempts int) time.Duration {
+	backoff := time.Duration(math.Pow(2, float64(attempts-1))) * 10 * time.Second
+	if backoff > time.Hour {
+		backoff = time.Hour
+	}
+	return backoff
+}
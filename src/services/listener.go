@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// listenChannel passes every payload announced on a Postgres channel to handle
+// until ctx is cancelled. A dropped connection is retried; payloads announced
+// meanwhile are missed.
+func listenChannel(ctx context.Context, databaseURL, channel string, handle func(payload string)) {
+	for ctx.Err() == nil {
+		if err := waitForNotifications(ctx, databaseURL, channel, handle); err != nil && ctx.Err() == nil {
+			log.Printf("Listener on %s failed, retrying: %v", channel, err)
+			select {
+			case <-time.After(5 * time.Second):
+			case <-ctx.Done():
+			}
+		}
+	}
+}
+
+func waitForNotifications(ctx context.Context, databaseURL, channel string, handle func(payload string)) error {
+	conn, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		event, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle(event.Payload)
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"gofiber-marketplace/src/models"
+	"log"
+	"strconv"
+	"sync"
+)
+
+type Notifier interface {
+	Notify(userId uint, kind models.NotificationType, title, message string) error
+}
+
+// LogNotifier writes notifications to the server log. It stands in for a
+// real delivery channel (email, push) during local development and testing.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(userId uint, kind models.NotificationType, title, message string) error {
+	log.Printf("notify user %d (%s): %s - %s", userId, kind, title, message)
+	return nil
+}
+
+// NotificationBroker fans new notifications out to the open SSE streams of a
+// user. Slow subscribers miss live pushes rather than blocking producers; the
+// notification itself is still stored and shows up in the list. Streams may
+// sit on a different process than the producer, so notifications reach the
+// broker through Postgres LISTEN/NOTIFY (see Listen).
+type NotificationBroker struct {
+	mu          sync.RWMutex
+	subscribers map[uint]map[chan *models.Notification]struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+func NewNotificationBroker() *NotificationBroker {
+	return &NotificationBroker{
+		subscribers: make(map[uint]map[chan *models.Notification]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Done is closed once the context passed to Listen is cancelled, so open
+// streams can end and let the server shut down.
+func (b *NotificationBroker) Done() <-chan struct{} {
+	return b.done
+}
+
+func (b *NotificationBroker) Subscribe(userId uint) (<-chan *models.Notification, func()) {
+	events := make(chan *models.Notification, 16)
+
+	b.mu.Lock()
+	if b.subscribers[userId] == nil {
+		b.subscribers[userId] = make(map[chan *models.Notification]struct{})
+	}
+	b.subscribers[userId][events] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.subscribers[userId], events)
+		if len(b.subscribers[userId]) == 0 {
+			delete(b.subscribers, userId)
+		}
+	}
+	return events, unsubscribe
+}
+
+func (b *NotificationBroker) Publish(userId uint, notification *models.Notification) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for events := range b.subscribers[userId] {
+		select {
+		case events <- notification:
+		default:
+		}
+	}
+}
+
+// Listen subscribes to new notifications from every process and publishes
+// them to this process's streams until ctx is cancelled, which also closes
+// Done. Notifications created while the connection is down are only missed
+// live.
+func (b *NotificationBroker) Listen(ctx context.Context, databaseURL string) {
+	defer b.closeOnce.Do(func() { close(b.done) })
+
+	listenChannel(ctx, databaseURL, models.NotificationChannel, func(payload string) {
+		id, err := strconv.Atoi(payload)
+		if err != nil {
+			return
+		}
+
+		if notification := models.SelectNotificationById(id); notification.ID != 0 {
+			b.Publish(notification.UserID, notification)
+		}
+	})
+}
+
+var DefaultBroker = NewNotificationBroker()
+
+// DatabaseNotifier stores notifications for the in-app notification center
+// and announces them, so every process pushes them to the recipient's open
+// streams.
+type DatabaseNotifier struct {
+	Broker *NotificationBroker
+}
+
+func (n DatabaseNotifier) Notify(userId uint, kind models.NotificationType, title, message string) error {
+	notification := models.Notification{
+		UserID:  userId,
+		Type:    kind,
+		Title:   title,
+		Message: message,
+	}
+
+	if err := models.CreateNotification(&notification); err != nil {
+		return err
+	}
+
+	if err := models.SignalNotification(notification.ID); err != nil {
+		log.Printf("Failed to announce notification %d, pushing locally only: %v", notification.ID, err)
+		n.Broker.Publish(userId, &notification)
+	}
+	return nil
+}
+
+var DefaultNotifier Notifier = DatabaseNotifier{Broker: DefaultBroker}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"gofiber-marketplace/src/models"
+)
+
+type ChargeRequest struct {
+	Amount   models.Money
+	Currency string
+	// Reference identifies the payment on the provider's side, so a retried
+	// charge can be matched to the one that already went through.
+	Reference string
+}
+
+type RefundRequest struct {
+	// ChargeReference is the provider's reference of the charge being
+	// refunded, as returned by Charge.
+	ChargeReference string
+	Amount          models.Money
+	Currency        string
+	Reference       string
+}
+
+type PaymentProvider interface {
+	Name() string
+	// Charge takes the amount from the customer and returns the provider's
+	// reference for the charge.
+	Charge(request ChargeRequest) (string, error)
+	// Refund gives all or part of a charge back to the customer and returns
+	// the provider's reference for the refund.
+	Refund(request RefundRequest) (string, error)
+}
+
+// LocalPaymentProvider accepts every charge without moving money. It stands
+// in for a payment gateway the way LocalShippingProvider stands in for
+// couriers.
+type LocalPaymentProvider struct{}
+
+func (p *LocalPaymentProvider) Name() string {
+	return "local"
+}
+
+func (p *LocalPaymentProvider) Charge(request ChargeRequest) (string, error) {
+	return localReference("ch_")
+}
+
+func (p *LocalPaymentProvider) Refund(request RefundRequest) (string, error) {
+	return localReference("re_")
+}
+
+func localReference(prefix string) (string, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(id), nil
+}
+
+var DefaultPaymentProvider PaymentProvider = &LocalPaymentProvider{}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gofiber-marketplace/src/models"
+	"math"
+	"sort"
+	"strings"
+)
+
+type ShippingRequest struct {
+	OriginCity            string
+	DestinationCity       string
+	DestinationPostalCode string
+	WeightGrams           int
+}
+
+type ShippingOption struct {
+	Provider string       `json:"provider"`
+	Courier  string       `json:"courier"`
+	Service  string       `json:"service"`
+	Cost     models.Money `json:"cost"`
+	Currency string       `json:"currency"`
+	DaysMin  int          `json:"days_min"`
+	DaysMax  int          `json:"days_max"`
+}
+
+type ShippingProvider interface {
+	Name() string
+	// Validate checks the provider's configuration. It runs at startup, so a
+	// broken config stops the server instead of failing quotes later.
+	Validate() error
+	Quote(request ShippingRequest) ([]ShippingOption, error)
+}
+
+var ErrUnsupportedDestination = errors.New("destination is not served")
+
+// ShippingProviderError reports which provider failed to quote.
+type ShippingProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ShippingProviderError) Error() string {
+	return fmt.Sprintf("shipping provider %s failed: %v", e.Provider, e.Err)
+}
+
+func (e *ShippingProviderError) Unwrap() error {
+	return e.Err
+}
+
+// QuoteShipping asks every provider for options and returns them cheapest
+// first. Providers that do not serve the destination are skipped.
+func QuoteShipping(request ShippingRequest) ([]ShippingOption, error) {
+	options := []ShippingOption{}
+	for _, provider := range DefaultShippingProviders {
+		providerOptions, err := provider.Quote(request)
+		if errors.Is(err, ErrUnsupportedDestination) {
+			continue
+		} else if err != nil {
+			return nil, &ShippingProviderError{Provider: provider.Name(), Err: err}
+		}
+		options = append(options, providerOptions...)
+	}
+	sort.Slice(options, func(a, b int) bool {
+		return options[a].Cost < options[b].Cost
+	})
+	return options, nil
+}
+
+type WeightBracket struct {
+	MaxGrams int
+	Cost     float64
+}
+
+// ShippingZone prices parcels to postal codes starting with one of its
+// prefixes. Parcels heavier than the last bracket pay ExtraPerKg for every
+// started kilogram above it.
+type ShippingZone struct {
+	Name       string
+	Prefixes   []string
+	Brackets   []WeightBracket
+	ExtraPerKg float64
+	DaysMin    int
+	DaysMax    int
+}
+
+type ShippingService struct {
+	Courier    string
+	Service    string
+	Multiplier float64
+	ExtraDays  int
+}
+
+// LocalShippingProvider quotes from an in-memory rate table instead of a
+// courier API. All rates in the table are in Currency.
+type LocalShippingProvider struct {
+	Currency string
+	Zones    []ShippingZone
+	Services []ShippingService
+	// SameCityDiscount is taken off when origin and destination city match.
+	SameCityDiscount float64
+}
+
+func (p *LocalShippingProvider) Name() string {
+	return "local"
+}
+
+func (p *LocalShippingProvider) Quote(request ShippingRequest) ([]ShippingOption, error) {
+	zone := p.zone(request.DestinationPostalCode)
+	if zone == nil {
+		return nil, ErrUnsupportedDestination
+	}
+
+	base, err := zone.cost(request.WeightGrams)
+	if err != nil {
+		return nil, err
+	}
+	if request.OriginCity != "" && strings.EqualFold(strings.TrimSpace(request.OriginCity), strings.TrimSpace(request.DestinationCity)) {
+		base *= 1 - p.SameCityDiscount
+	}
+
+	options := make([]ShippingOption, len(p.Services))
+	for i, service := range p.Services {
+		options[i] = ShippingOption{
+			Provider: p.Name(),
+			Courier:  service.Courier,
+			Service:  service.Service,
+			Cost:     models.NewMoney(math.Ceil(base*service.Multiplier/100) * 100),
+			Currency: p.Currency,
+			DaysMin:  max(zone.DaysMin+service.ExtraDays, 1),
+			DaysMax:  max(zone.DaysMax+service.ExtraDays, 1),
+		}
+	}
+	return options, nil
+}
+
+// zone picks the zone with the longest matching prefix, so a specific
+// prefix such as "102" wins over a broader "1".
+func (p *LocalShippingProvider) zone(postalCode string) *ShippingZone {
+	var match *ShippingZone
+	matchLength := 0
+	for i := range p.Zones {
+		for _, prefix := range p.Zones[i].Prefixes {
+			if strings.HasPrefix(postalCode, prefix) && len(prefix) > matchLength {
+				match = &p.Zones[i]
+				matchLength = len(prefix)
+			}
+		}
+	}
+	return match
+}
+
+func (p *LocalShippingProvider) Validate() error {
+	if len(p.Currency) != 3 {
+		return fmt.Errorf("%s shipping provider: currency must contain a 3-letter code", p.Name())
+	}
+	if len(p.Zones) == 0 || len(p.Services) == 0 {
+		return fmt.Errorf("%s shipping provider: zones and services must not be empty", p.Name())
+	}
+	if p.SameCityDiscount < 0 || p.SameCityDiscount >= 1 {
+		return fmt.Errorf("%s shipping provider: same city discount must be between 0 and 1", p.Name())
+	}
+
+	for _, zone := range p.Zones {
+		if err := zone.validate(); err != nil {
+			return fmt.Errorf("%s shipping provider: zone %s: %w", p.Name(), zone.Name, err)
+		}
+	}
+	for _, service := range p.Services {
+		if service.Multiplier <= 0 {
+			return fmt.Errorf("%s shipping provider: service %s: multiplier must be positive", p.Name(), service.Service)
+		}
+	}
+	return nil
+}
+
+func (z *ShippingZone) validate() error {
+	if len(z.Prefixes) == 0 {
+		return errors.New("prefixes must not be empty")
+	}
+	if len(z.Brackets) == 0 {
+		return errors.New("weight brackets must not be empty")
+	}
+	for i, bracket := range z.Brackets {
+		if bracket.Cost < 0 || (i > 0 && bracket.MaxGrams <= z.Brackets[i-1].MaxGrams) {
+			return errors.New("weight brackets must have a cost and ascending weights")
+		}
+	}
+	if z.ExtraPerKg < 0 || z.DaysMin > z.DaysMax {
+		return errors.New("extra cost and delivery days must be valid")
+	}
+	return nil
+}
+
+func (z *ShippingZone) cost(weightGrams int) (float64, error) {
+	if len(z.Brackets) == 0 {
+		return 0, fmt.Errorf("shipping zone %s has no weight brackets", z.Name)
+	}
+
+	for _, bracket := range z.Brackets {
+		if weightGrams <= bracket.MaxGrams {
+			return bracket.Cost, nil
+		}
+	}
+
+	last := z.Brackets[len(z.Brackets)-1]
+	extraKg := math.Ceil(float64(weightGrams-last.MaxGrams) / 1000)
+	return last.Cost + extraKg*z.ExtraPerKg, nil
+}
+
+var defaultBrackets = func(base float64) []WeightBracket {
+	return []WeightBracket{
+		{MaxGrams: 1000, Cost: base},
+		{MaxGrams: 3000, Cost: base * 2},
+		{MaxGrams: 5000, Cost: base * 3},
+	}
+}
+
+var DefaultShippingProviders = []ShippingProvider{
+	&LocalShippingProvider{
+		Currency: "IDR",
+		Zones: []ShippingZone{
+			{Name: "jabodetabek", Prefixes: []string{"10", "11", "12", "13", "14", "15", "16", "17"}, Brackets: defaultBrackets(10000), ExtraPerKg: 8000, DaysMin: 1, DaysMax: 2},
+			{Name: "java", Prefixes: []string{"4", "5", "6"}, Brackets: defaultBrackets(15000), ExtraPerKg: 12000, DaysMin: 2, DaysMax: 3},
+			{Name: "sumatra", Prefixes: []string{"2", "3"}, Brackets: defaultBrackets(25000), ExtraPerKg: 20000, DaysMin: 3, DaysMax: 5},
+			{Name: "eastern", Prefixes: []string{"7", "8", "9"}, Brackets: defaultBrackets(40000), ExtraPerKg: 35000, DaysMin: 4, DaysMax: 7},
+		},
+		Services: []ShippingService{
+			{Courier: "Local Courier", Service: "ECO", Multiplier: 0.8, ExtraDays: 2},
+			{Courier: "Local Courier", Service: "REG", Multiplier: 1},
+			{Courier: "Local Courier", Service: "YES", Multiplier: 1.8, ExtraDays: -1},
+		},
+		SameCityDiscount: 0.3,
+	},
+}